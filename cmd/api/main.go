@@ -1,14 +1,23 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"strconv"
+	"strings"
 	"time"
+	_ "time/tzdata" // IANA zones for task date handling, even without system tzdata
 
 	_ "github.com/Swarnadip-Dey/Collaborative-taskmanager/docs"
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository/postgres"
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/routes"
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/services"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/auth"
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/db"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/events"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/mail"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/storage"
 	"github.com/joho/godotenv"
 )
 
@@ -54,8 +63,70 @@ func main() {
 	// Repository initialization
 	repo := postgres.NewRepository(database)
 
+	// Bootstrap the first admin account if requested
+	if email := os.Getenv("BOOTSTRAP_ADMIN_EMAIL"); email != "" {
+		username := os.Getenv("BOOTSTRAP_ADMIN_USERNAME")
+		if username == "" {
+			username = "admin"
+		}
+		admin, err := services.NewUserService(repo).BootstrapAdmin(
+			context.Background(), email, username, os.Getenv("BOOTSTRAP_ADMIN_PASSWORD"),
+		)
+		if err != nil {
+			log.Fatalf("Failed to bootstrap admin: %v", err)
+		}
+		if admin != nil {
+			log.Printf("Bootstrapped admin account %s", admin.Email)
+		}
+	}
+
+	// Purge expired refresh tokens and revocations every hour
+	services.StartTokenCleanup(repo, time.Hour)
+
+	// Outgoing email for notifications
+	mailer, err := mail.Load()
+	if err != nil {
+		log.Fatalf("Failed to set up mail: %v", err)
+	}
+
+	// Task events are kept for replay until this many newer ones have been
+	// published, across all projects.
+	hub := events.NewHub(1000)
+
+	// Flag tasks that pass their due date
+	services.StartOverdueMonitor(repo, hub, services.NewNotificationService(repo, mailer), 5*time.Minute)
+
+	// Email daily digests at DIGEST_HOUR (UTC, default 8)
+	digestHour := 8
+	if value, err := strconv.Atoi(os.Getenv("DIGEST_HOUR")); err == nil && value >= 0 && value < 24 {
+		digestHour = value
+	}
+	services.StartEmailDigest(repo, mailer, digestHour)
+
+	// Send queued webhook deliveries and retry failed ones
+	services.StartWebhookDispatcher(repo, services.NewWebhookClient(), 5*time.Second)
+
+	// Attachment storage
+	store, err := storage.Load()
+	if err != nil {
+		log.Fatalf("Failed to set up attachment storage: %v", err)
+	}
+
+	// Purge attachments of tasks that have been archived for a while
+	retention := 30 * 24 * time.Hour
+	if value, err := time.ParseDuration(os.Getenv("ATTACHMENT_RETENTION")); err == nil && value > 0 {
+		retention = value
+	}
+	services.StartAttachmentCleanup(repo, store, time.Hour, retention)
+
+	// Load JWT signing keys
+	keys, err := auth.LoadKeyManager()
+	if err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+
 	// Setup routes
-	r := routes.SetupRouter(repo)
+	r := routes.SetupRouter(repo, keys, store, attachmentLimits(), mailer, hub)
 
 	// Start server
 	log.Println("Server starting on :8080")
@@ -63,3 +134,21 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// attachmentLimits applies ATTACHMENT_MAX_BYTES and ATTACHMENT_ALLOWED_TYPES
+// (comma-separated media types) on top of the defaults.
+func attachmentLimits() services.AttachmentLimits {
+	limits := services.DefaultAttachmentLimits
+	if value, err := strconv.ParseInt(os.Getenv("ATTACHMENT_MAX_BYTES"), 10, 64); err == nil && value > 0 {
+		limits.MaxSize = value
+	}
+	if spec := os.Getenv("ATTACHMENT_ALLOWED_TYPES"); spec != "" {
+		limits.AllowedTypes = nil
+		for _, mediaType := range strings.Split(spec, ",") {
+			if mediaType = strings.ToLower(strings.TrimSpace(mediaType)); mediaType != "" {
+				limits.AllowedTypes = append(limits.AllowedTypes, mediaType)
+			}
+		}
+	}
+	return limits
+}
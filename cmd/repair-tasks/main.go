@@ -0,0 +1,51 @@
+// Command repair-tasks finds tasks with a status or priority that is no longer
+// allowed and fixes them. It only reports by default; pass -apply to write the
+// fixes and validate the task CHECK constraints afterwards.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository/postgres"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/services"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/db"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	apply := flag.Bool("apply", false, "write the fixes instead of only reporting them")
+	flag.Parse()
+
+	// Load .env file
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	database, err := db.Connect()
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	if err := db.Migrate(database); err != nil {
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	repo := postgres.NewRepository(database)
+	repairs, err := services.NewTaskService(repo, nil, nil).RepairInvalidTasks(context.Background(), *apply)
+	for _, repair := range repairs {
+		log.Printf("task %d: %s %q -> %q", repair.TaskID, repair.Field, repair.From, repair.To)
+	}
+	if err != nil {
+		log.Fatalf("Failed to repair tasks: %v", err)
+	}
+
+	if !*apply {
+		log.Printf("Found %d invalid values; run with -apply to fix them", len(repairs))
+		return
+	}
+	if err := db.ValidateTaskConstraints(database); err != nil {
+		log.Fatalf("Failed to validate task constraints: %v", err)
+	}
+	log.Printf("Fixed %d invalid values; task constraints validated", len(repairs))
+}
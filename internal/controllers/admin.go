@@ -0,0 +1,278 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type AdminController struct {
+	userService *services.UserService
+}
+
+func NewAdminController(userService *services.UserService) *AdminController {
+	return &AdminController{userService: userService}
+}
+
+type ChangeRoleRequest struct {
+	Role models.UserRole `json:"role" binding:"required"`
+}
+
+type PasswordResetResponse struct {
+	TemporaryPassword string `json:"temporary_password"`
+}
+
+// ListUsers godoc
+// @Summary List users
+// @Description Admin can page through users, optionally searching by username/email and filtering by role
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param q query string false "Case-insensitive username or email substring"
+// @Param role query string false "Only users with this role (admin, manager, dev)"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Success 200 {object} services.UserPage
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/users [get]
+func (ac *AdminController) ListUsers(c *gin.Context) {
+	page, pageSize, err := parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	users, err := ac.userService.ListUsers(c.Request.Context(), services.UserQuery{
+		Search:   c.Query("q"),
+		Role:     models.UserRole(c.Query("role")),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// GetUser godoc
+// @Summary Get user by ID
+// @Description Admin can view any user account
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} models.User
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/users/{id} [get]
+func (ac *AdminController) GetUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	user, err := ac.userService.GetUser(c.Request.Context(), uint(id))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// DisableUser godoc
+// @Summary Disable a user
+// @Description Admin can disable an account. Disabled users cannot log in and their tokens are rejected. The last enabled admin cannot be disabled.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} models.User
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/users/{id}/disable [post]
+func (ac *AdminController) DisableUser(c *gin.Context) {
+	ac.setDisabled(c, true)
+}
+
+// EnableUser godoc
+// @Summary Re-enable a user
+// @Description Admin can re-enable a disabled account
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} models.User
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/users/{id}/enable [post]
+func (ac *AdminController) EnableUser(c *gin.Context) {
+	ac.setDisabled(c, false)
+}
+
+func (ac *AdminController) setDisabled(c *gin.Context, disabled bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	user, err := ac.userService.SetDisabled(c.Request.Context(), currentActor(c), uint(id), disabled)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ResetUserPassword godoc
+// @Summary Force a password reset
+// @Description Admin can replace a user's password with a temporary one. The user must change it via PUT /api/profile/password before using any other endpoint.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} PasswordResetResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/users/{id}/reset-password [post]
+func (ac *AdminController) ResetUserPassword(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	password, err := ac.userService.ForcePasswordReset(c.Request.Context(), currentActor(c), uint(id))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, PasswordResetResponse{TemporaryPassword: password})
+}
+
+// DeleteUser godoc
+// @Summary Delete a user
+// @Description Admin can delete a user. The account is anonymized and disabled, workspace memberships are removed, and assigned tasks are reassigned to reassign_to or unassigned.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param reassign_to query int false "User who takes over the deleted user's tasks"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/users/{id} [delete]
+func (ac *AdminController) DeleteUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	var reassignTo *uint
+	if v := c.Query("reassign_to"); v != "" {
+		parsed, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid reassign_to"})
+			return
+		}
+		target := uint(parsed)
+		reassignTo = &target
+	}
+
+	if err := ac.userService.DeleteUser(c.Request.Context(), currentActor(c), uint(id), reassignTo); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ChangeUserRole godoc
+// @Summary Change a user's role
+// @Description Admin can promote or demote a user. The change is recorded in the audit log and the last admin cannot be demoted.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body ChangeRoleRequest true "New role"
+// @Success 200 {object} models.User
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/users/{id}/role [put]
+func (ac *AdminController) ChangeUserRole(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	var req ChangeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := ac.userService.ChangeRole(c.Request.Context(), currentActor(c), uint(id), req.Role)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// ListAuditLogs godoc
+// @Summary List audit log entries
+// @Description Admin can view administrative actions such as role changes, newest first
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Success 200 {object} services.AuditLogPage
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/admin/audit-logs [get]
+func (ac *AdminController) ListAuditLogs(c *gin.Context) {
+	page, pageSize, err := parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	logs, err := ac.userService.ListAuditLogs(c.Request.Context(), page, pageSize)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, logs)
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead allows for the multipart framing around an uploaded file
+// when limiting the request body size.
+const multipartOverhead = 1 << 20
+
+// ListAttachments godoc
+// @Summary List task attachments
+// @Description Workspace members can list the files attached to a task, oldest first
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Success 200 {array} models.Attachment
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/tasks/{id}/attachments [get]
+func (dc *DevController) ListAttachments(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task ID"})
+		return
+	}
+
+	attachments, err := dc.attachmentService.ListAttachments(c.Request.Context(), currentActor(c), uint(taskID))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// UploadAttachment godoc
+// @Summary Attach a file to a task
+// @Description Developer can upload a screenshot, log or archive to a task. The file type is detected from its contents.
+// @Tags developer
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param file formData file true "File to attach"
+// @Success 201 {object} models.Attachment
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/tasks/{id}/attachments [post]
+func (dc *DevController) UploadAttachment(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task ID"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, dc.attachmentService.MaxSize()+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "multipart form with a file field is required"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read upload"})
+		return
+	}
+	defer file.Close()
+
+	attachment, err := dc.attachmentService.UploadAttachment(c.Request.Context(), currentActor(c), uint(taskID), services.UploadAttachmentInput{
+		FileName: header.Filename,
+		Size:     header.Size,
+		Content:  file,
+	})
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// DownloadAttachment godoc
+// @Summary Download a task attachment
+// @Description Workspace members can download a file attached to a task
+// @Tags developer
+// @Produce octet-stream
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param attachment_id path int true "Attachment ID"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/tasks/{id}/attachments/{attachment_id} [get]
+func (dc *DevController) DownloadAttachment(c *gin.Context) {
+	taskID, attachmentID, ok := parseAttachmentPath(c)
+	if !ok {
+		return
+	}
+
+	attachment, content, err := dc.attachmentService.OpenAttachment(c.Request.Context(), currentActor(c), taskID, attachmentID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	defer content.Close()
+
+	// Always download rather than render, so uploaded files cannot run as
+	// pages of this origin.
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, content, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// DeleteAttachment godoc
+// @Summary Delete a task attachment
+// @Description The uploader or a workspace manager can remove an attachment
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param attachment_id path int true "Attachment ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/tasks/{id}/attachments/{attachment_id} [delete]
+func (dc *DevController) DeleteAttachment(c *gin.Context) {
+	taskID, attachmentID, ok := parseAttachmentPath(c)
+	if !ok {
+		return
+	}
+
+	if err := dc.attachmentService.DeleteAttachment(c.Request.Context(), currentActor(c), taskID, attachmentID); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parseAttachmentPath reads the task and attachment IDs from the path,
+// responding with 400 when either is malformed.
+func parseAttachmentPath(c *gin.Context) (uint, uint, bool) {
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task ID"})
+		return 0, 0, false
+	}
+	attachmentID, err := strconv.ParseUint(c.Param("attachment_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid attachment ID"})
+		return 0, 0, false
+	}
+	return uint(taskID), uint(attachmentID), true
+}
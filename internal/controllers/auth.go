@@ -5,23 +5,27 @@ import (
 
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/services"
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/auth"
 	"github.com/gin-gonic/gin"
 )
 
 type AuthController struct {
-	repo repository.Repository
+	repo         repository.Repository
+	tokenService *services.TokenService
 }
 
-func NewAuthController(repo repository.Repository) *AuthController {
-	return &AuthController{repo: repo}
+func NewAuthController(repo repository.Repository, tokenService *services.TokenService) *AuthController {
+	return &AuthController{
+		repo:         repo,
+		tokenService: tokenService,
+	}
 }
 
 type RegisterRequest struct {
-	Username string          `json:"username" binding:"required"`
-	Email    string          `json:"email" binding:"required,email"`
-	Password string          `json:"password" binding:"required,min=6"`
-	Role     models.UserRole `json:"role"`
+	Username string `json:"username" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
 }
 
 type LoginRequest struct {
@@ -29,14 +33,27 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 type AuthResponse struct {
-	Token string       `json:"token"`
-	User  *models.User `json:"user"`
+	services.TokenPair
+	User *models.User `json:"user"`
 }
 
 // Register godoc
 // @Summary Register a new user
-// @Description Create a new user account with username, email, password and role
+// @Description Create a new developer account with username, email and password. Other roles are granted by an admin.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -52,17 +69,6 @@ func (ac *AuthController) Register(c *gin.Context) {
 		return
 	}
 
-	// Default role to dev if not specified
-	if req.Role == "" {
-		req.Role = models.RoleDev
-	}
-
-	// Validate role
-	if req.Role != models.RoleAdmin && req.Role != models.RoleManager && req.Role != models.RoleDev {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role"})
-		return
-	}
-
 	// Hash password
 	hashedPassword, err := auth.HashPassword(req.Password)
 	if err != nil {
@@ -75,7 +81,8 @@ func (ac *AuthController) Register(c *gin.Context) {
 		Username:     req.Username,
 		Email:        req.Email,
 		PasswordHash: hashedPassword,
-		Role:         req.Role,
+		// Self-service accounts are always developers; admins grant other roles.
+		Role: models.RoleDev,
 	}
 
 	if err := ac.repo.Users().Create(c.Request.Context(), user); err != nil {
@@ -83,16 +90,16 @@ func (ac *AuthController) Register(c *gin.Context) {
 		return
 	}
 
-	// Generate token
-	token, err := auth.GenerateToken(user)
+	// Generate tokens
+	tokens, err := ac.tokenService.IssueTokens(c.Request.Context(), user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
 		return
 	}
 
 	c.JSON(http.StatusCreated, AuthResponse{
-		Token: token,
-		User:  user,
+		TokenPair: *tokens,
+		User:      user,
 	})
 }
 
@@ -127,16 +134,21 @@ func (ac *AuthController) Login(c *gin.Context) {
 		return
 	}
 
-	// Generate token
-	token, err := auth.GenerateToken(user)
+	if user.IsDisabled() {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "account disabled"})
+		return
+	}
+
+	// Generate tokens
+	tokens, err := ac.tokenService.IssueTokens(c.Request.Context(), user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
 		return
 	}
 
 	c.JSON(http.StatusOK, AuthResponse{
-		Token: token,
-		User:  user,
+		TokenPair: *tokens,
+		User:      user,
 	})
 }
 
@@ -166,3 +178,134 @@ func (ac *AuthController) GetProfile(c *gin.Context) {
 
 	c.JSON(http.StatusOK, user)
 }
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Change the authenticated user's password and revoke all refresh tokens. This is the only endpoint available while an admin-forced password reset is pending.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body ChangePasswordRequest true "Current and new password"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/profile/password [put]
+func (ac *AuthController) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	user, err := ac.repo.Users().GetByID(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	if !auth.CheckPassword(req.CurrentPassword, user.PasswordHash) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return
+	}
+
+	hashedPassword, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+		return
+	}
+
+	user.PasswordHash = hashedPassword
+	user.PasswordResetRequired = false
+
+	if err := ac.repo.Users().Update(c.Request.Context(), user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update password"})
+		return
+	}
+
+	// Sign out other devices that may know the old password
+	if err := ac.tokenService.RevokeUserSessions(c.Request.Context(), user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RefreshToken godoc
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token and refresh token. The presented refresh token is revoked; reusing it revokes the whole session.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RefreshRequest true "Refresh token"
+// @Success 200 {object} AuthResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/token/refresh [post]
+func (ac *AuthController) RefreshToken(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, user, err := ac.tokenService.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, AuthResponse{
+		TokenPair: *tokens,
+		User:      user,
+	})
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description Revoke the current access token and, if given, the session's refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body LogoutRequest false "Refresh token of the session"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/logout [post]
+func (ac *AuthController) Logout(c *gin.Context) {
+	var req LogoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	tokenID := c.GetString("token_id")
+	expiresAt := c.GetTime("token_expires_at")
+
+	if err := ac.tokenService.Logout(c.Request.Context(), currentActor(c), tokenID, expiresAt, req.RefreshToken); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// JWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys for verifying access tokens issued by this API, identified by the kid token header
+// @Tags auth
+// @Produce json
+// @Success 200 {object} auth.JSONWebKeySet
+// @Router /.well-known/jwks.json [get]
+func (ac *AuthController) JWKS(c *gin.Context) {
+	c.JSON(http.StatusOK, ac.tokenService.JWKS())
+}
@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CommentRequest struct {
+	Body string `json:"body" binding:"required"` // Markdown
+}
+
+// ListComments godoc
+// @Summary List task comments
+// @Description Developer can page through the comments on a task, oldest first
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Success 200 {object} services.CommentPage
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/tasks/{id}/comments [get]
+func (dc *DevController) ListComments(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task ID"})
+		return
+	}
+	page, pageSize, err := parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comments, err := dc.commentService.ListComments(c.Request.Context(), currentActor(c), uint(taskID), page, pageSize)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, comments)
+}
+
+// CreateComment godoc
+// @Summary Comment on a task
+// @Description Developer can leave a Markdown comment on a task. @username mentions of workspace members are recorded.
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param request body CommentRequest true "Comment"
+// @Success 201 {object} models.TaskComment
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/tasks/{id}/comments [post]
+func (dc *DevController) CreateComment(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task ID"})
+		return
+	}
+
+	var req CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment, err := dc.commentService.CreateComment(c.Request.Context(), currentActor(c), uint(taskID), req.Body)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, comment)
+}
+
+// UpdateComment godoc
+// @Summary Edit a comment
+// @Description The author of a comment can change its body. The previous body is kept in the comment's edit history.
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param comment_id path int true "Comment ID"
+// @Param request body CommentRequest true "Comment"
+// @Success 200 {object} models.TaskComment
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/tasks/{id}/comments/{comment_id} [put]
+func (dc *DevController) UpdateComment(c *gin.Context) {
+	taskID, commentID, ok := parseCommentParams(c)
+	if !ok {
+		return
+	}
+
+	var req CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment, err := dc.commentService.UpdateComment(c.Request.Context(), currentActor(c), taskID, commentID, req.Body)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, comment)
+}
+
+// DeleteComment godoc
+// @Summary Delete a comment
+// @Description The author of a comment, or a workspace manager, can delete it
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param comment_id path int true "Comment ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/tasks/{id}/comments/{comment_id} [delete]
+func (dc *DevController) DeleteComment(c *gin.Context) {
+	taskID, commentID, ok := parseCommentParams(c)
+	if !ok {
+		return
+	}
+
+	if err := dc.commentService.DeleteComment(c.Request.Context(), currentActor(c), taskID, commentID); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListCommentEdits godoc
+// @Summary Get comment edit history
+// @Description Developer can view the previous bodies of a comment, newest first
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param comment_id path int true "Comment ID"
+// @Success 200 {array} models.TaskCommentEdit
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/tasks/{id}/comments/{comment_id}/edits [get]
+func (dc *DevController) ListCommentEdits(c *gin.Context) {
+	taskID, commentID, ok := parseCommentParams(c)
+	if !ok {
+		return
+	}
+
+	edits, err := dc.commentService.ListCommentEdits(c.Request.Context(), currentActor(c), taskID, commentID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, edits)
+}
+
+// parseCommentParams reads the task and comment IDs from the path, writing a
+// 400 response and returning false if either is malformed.
+func parseCommentParams(c *gin.Context) (uint, uint, bool) {
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task ID"})
+		return 0, 0, false
+	}
+	commentID, err := strconv.ParseUint(c.Param("comment_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid comment ID"})
+		return 0, 0, false
+	}
+	return uint(taskID), uint(commentID), true
+}
@@ -0,0 +1,18 @@
+package controllers
+
+import (
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// currentActor builds the services.Actor for the user authenticated by
+// middleware.AuthMiddleware.
+func currentActor(c *gin.Context) services.Actor {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("user_role")
+	return services.Actor{
+		UserID: userID.(uint),
+		Role:   role.(models.UserRole),
+	}
+}
@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type CreateCustomFieldRequest struct {
+	// Key identifies the field in task custom_fields and in cf.<key> filters.
+	Key  string                 `json:"key" binding:"required"`
+	Name string                 `json:"name" binding:"required"`
+	Type models.CustomFieldType `json:"type" binding:"required"`
+	// Options lists the choices of select and multi_select fields.
+	Options  []string `json:"options"`
+	Required bool     `json:"required"`
+	Position *int     `json:"position"`
+}
+
+// UpdateCustomFieldRequest changes the given settings; a field's key and type
+// cannot change.
+type UpdateCustomFieldRequest struct {
+	Name     *string  `json:"name"`
+	Options  []string `json:"options"`
+	Required *bool    `json:"required"`
+	Position *int     `json:"position"`
+}
+
+// ListCustomFields godoc
+// @Summary List project custom fields
+// @Description Workspace members can list the custom fields defined for a project's tasks
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Success 200 {array} models.CustomField
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/projects/{id}/fields [get]
+func (dc *DevController) ListCustomFields(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	fields, err := dc.customFieldService.ListFields(c.Request.Context(), currentActor(c), uint(projectID))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, fields)
+}
+
+// CreateCustomField godoc
+// @Summary Create a custom field
+// @Description Workspace managers can add a text, number, date, select, multi_select or user field to a project's tasks. A required field can only be added while the project has no tasks.
+// @Tags manager
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Param request body CreateCustomFieldRequest true "Custom field definition"
+// @Success 201 {object} models.CustomField
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/manager/projects/{id}/fields [post]
+func (mc *ManagerController) CreateCustomField(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	var req CreateCustomFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	field, err := mc.customFieldService.CreateField(c.Request.Context(), currentActor(c), uint(projectID), services.CreateCustomFieldInput{
+		Key:      req.Key,
+		Name:     req.Name,
+		Type:     req.Type,
+		Options:  req.Options,
+		Required: req.Required,
+		Position: req.Position,
+	})
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, field)
+}
+
+// UpdateCustomField godoc
+// @Summary Update a custom field
+// @Description Workspace managers can rename a custom field, change its options, position or whether it is required. Options still used by a task cannot be removed.
+// @Tags manager
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Custom field ID"
+// @Param request body UpdateCustomFieldRequest true "Settings to change"
+// @Success 200 {object} models.CustomField
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/manager/fields/{id} [put]
+func (mc *ManagerController) UpdateCustomField(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid custom field ID"})
+		return
+	}
+
+	var req UpdateCustomFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	field, err := mc.customFieldService.UpdateField(c.Request.Context(), currentActor(c), uint(id), services.UpdateCustomFieldInput{
+		Name:     req.Name,
+		Options:  req.Options,
+		Required: req.Required,
+		Position: req.Position,
+	})
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, field)
+}
+
+// DeleteCustomField godoc
+// @Summary Delete a custom field
+// @Description Workspace managers can remove a custom field; every task's value for it is removed too
+// @Tags manager
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Custom field ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/manager/fields/{id} [delete]
+func (mc *ManagerController) DeleteCustomField(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid custom field ID"})
+		return
+	}
+
+	if err := mc.customFieldService.DeleteField(c.Request.Context(), currentActor(c), uint(id)); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddDependencyRequest links the task to another one. Exactly one of the
+// fields must be set.
+type AddDependencyRequest struct {
+	// BlockedBy is a task that must be completed before this one.
+	BlockedBy *uint `json:"blocked_by"`
+	// Blocks is a task that waits for this one.
+	Blocks *uint `json:"blocks"`
+}
+
+// ListDependencies godoc
+// @Summary List task dependencies
+// @Description Developer can list the tasks a task is blocked by and the tasks it blocks
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Success 200 {object} services.TaskDependencies
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/tasks/{id}/dependencies [get]
+func (dc *DevController) ListDependencies(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task ID"})
+		return
+	}
+
+	dependencies, err := dc.taskService.ListDependencies(c.Request.Context(), currentActor(c), uint(id))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dependencies)
+}
+
+// AddDependency godoc
+// @Summary Add a task dependency
+// @Description Developer can record that a task is blocked by, or blocks, another task in the same workspace. Links that would create a cycle are rejected.
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param request body AddDependencyRequest true "Linked task"
+// @Success 201 {object} models.TaskDependency
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/tasks/{id}/dependencies [post]
+func (dc *DevController) AddDependency(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task ID"})
+		return
+	}
+
+	var req AddDependencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var blockingID, blockedID uint
+	switch {
+	case req.BlockedBy != nil && req.Blocks == nil:
+		blockingID, blockedID = *req.BlockedBy, uint(id)
+	case req.Blocks != nil && req.BlockedBy == nil:
+		blockingID, blockedID = uint(id), *req.Blocks
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exactly one of blocked_by or blocks is required"})
+		return
+	}
+
+	dependency, err := dc.taskService.AddDependency(c.Request.Context(), currentActor(c), blockingID, blockedID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dependency)
+}
+
+// RemoveDependency godoc
+// @Summary Remove a task dependency
+// @Description Developer can remove the dependency between two tasks, whichever of them is the blocking one
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param other_id path int true "ID of the linked task"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/tasks/{id}/dependencies/{other_id} [delete]
+func (dc *DevController) RemoveDependency(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task ID"})
+		return
+	}
+	otherID, err := strconv.ParseUint(c.Param("other_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid linked task ID"})
+		return
+	}
+
+	if err := dc.taskService.RemoveDependency(c.Request.Context(), currentActor(c), uint(id), uint(otherID)); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
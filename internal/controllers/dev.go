@@ -1,8 +1,12 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/services"
@@ -10,17 +14,38 @@ import (
 )
 
 type DevController struct {
-	taskService    *services.TaskService
-	projectService *services.ProjectService
+	taskService        *services.TaskService
+	projectService     *services.ProjectService
+	historyService     *services.HistoryService
+	workspaceService   *services.WorkspaceService
+	workflowService    *services.WorkflowService
+	commentService     *services.CommentService
+	labelService       *services.LabelService
+	customFieldService *services.CustomFieldService
+	attachmentService  *services.AttachmentService
 }
 
 func NewDevController(
 	taskService *services.TaskService,
 	projectService *services.ProjectService,
+	historyService *services.HistoryService,
+	workspaceService *services.WorkspaceService,
+	workflowService *services.WorkflowService,
+	commentService *services.CommentService,
+	labelService *services.LabelService,
+	customFieldService *services.CustomFieldService,
+	attachmentService *services.AttachmentService,
 ) *DevController {
 	return &DevController{
-		taskService:    taskService,
-		projectService: projectService,
+		taskService:        taskService,
+		projectService:     projectService,
+		historyService:     historyService,
+		workspaceService:   workspaceService,
+		workflowService:    workflowService,
+		commentService:     commentService,
+		labelService:       labelService,
+		customFieldService: customFieldService,
+		attachmentService:  attachmentService,
 	}
 }
 
@@ -30,6 +55,16 @@ type CreateTaskRequest struct {
 	Status      models.TaskStatus   `json:"status"`
 	Priority    models.TaskPriority `json:"priority"`
 	ProjectID   uint                `json:"project_id" binding:"required"`
+	// ParentID makes the new task a subtask of another task in the project.
+	ParentID *uint `json:"parent_id"`
+	// Dates are RFC3339 timestamps or YYYY-MM-DD dates in Timezone.
+	StartDate string `json:"start_date"`
+	DueDate   string `json:"due_date"`
+	// Timezone is an IANA zone name such as Europe/Berlin; defaults to UTC.
+	Timezone string `json:"timezone"`
+	// CustomFields holds values for the project's custom fields, keyed by
+	// field key.
+	CustomFields map[string]interface{} `json:"custom_fields"`
 }
 
 type UpdateTaskRequest struct {
@@ -37,6 +72,13 @@ type UpdateTaskRequest struct {
 	Description string              `json:"description"`
 	Status      models.TaskStatus   `json:"status"`
 	Priority    models.TaskPriority `json:"priority"`
+	// Dates are RFC3339 timestamps or YYYY-MM-DD dates in Timezone; an empty
+	// string removes the date.
+	StartDate *string `json:"start_date"`
+	DueDate   *string `json:"due_date"`
+	Timezone  string  `json:"timezone"`
+	// CustomFields sets the given custom field values; null clears one.
+	CustomFields map[string]interface{} `json:"custom_fields"`
 }
 
 // CreateTask godoc
@@ -50,6 +92,9 @@ type UpdateTaskRequest struct {
 // @Success 201 {object} models.Task
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/dev/tasks [post]
 func (dc *DevController) CreateTask(c *gin.Context) {
@@ -59,21 +104,41 @@ func (dc *DevController) CreateTask(c *gin.Context) {
 		return
 	}
 
-	userID, _ := c.Get("user_id")
-	assigneeID := userID.(uint)
+	loc, err := parseTimezone(req.Timezone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	startDate, err := parseTaskDate("start_date", req.StartDate, loc, false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dueDate, err := parseTaskDate("due_date", req.DueDate, loc, true)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actor := currentActor(c)
+	assigneeID := actor.UserID
 
 	input := services.CreateTaskInput{
-		Title:       req.Title,
-		Description: req.Description,
-		Status:      req.Status,
-		Priority:    req.Priority,
-		AssigneeID:  &assigneeID,
-		ProjectID:   req.ProjectID,
+		Title:        req.Title,
+		Description:  req.Description,
+		Status:       req.Status,
+		Priority:     req.Priority,
+		AssigneeID:   &assigneeID,
+		ProjectID:    req.ProjectID,
+		ParentID:     req.ParentID,
+		StartDate:    startDate,
+		DueDate:      dueDate,
+		CustomFields: req.CustomFields,
 	}
 
-	task, err := dc.taskService.CreateTask(c.Request.Context(), input)
+	task, err := dc.taskService.CreateTask(c.Request.Context(), actor, input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondServiceError(c, err)
 		return
 	}
 
@@ -90,6 +155,7 @@ func (dc *DevController) CreateTask(c *gin.Context) {
 // @Param id path int true "Task ID"
 // @Success 200 {object} models.Task
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Router /api/dev/tasks/{id} [get]
 func (dc *DevController) GetTask(c *gin.Context) {
@@ -99,9 +165,9 @@ func (dc *DevController) GetTask(c *gin.Context) {
 		return
 	}
 
-	task, err := dc.taskService.GetTask(c.Request.Context(), uint(id))
+	task, err := dc.taskService.GetTask(c.Request.Context(), currentActor(c), uint(id))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		respondServiceError(c, err)
 		return
 	}
 
@@ -110,7 +176,7 @@ func (dc *DevController) GetTask(c *gin.Context) {
 
 // UpdateTask godoc
 // @Summary Update a task
-// @Description Developer can update task details (title, description, status, priority)
+// @Description Developer can update task details (title, description, status, priority). Status changes must follow the project's workflow, and a task cannot be completed while it has open subtasks.
 // @Tags developer
 // @Accept json
 // @Produce json
@@ -119,7 +185,9 @@ func (dc *DevController) GetTask(c *gin.Context) {
 // @Param request body UpdateTaskRequest true "Updated task details"
 // @Success 200 {object} models.Task
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/dev/tasks/{id} [put]
 func (dc *DevController) UpdateTask(c *gin.Context) {
@@ -149,9 +217,90 @@ func (dc *DevController) UpdateTask(c *gin.Context) {
 		input.Priority = &req.Priority
 	}
 
-	task, err := dc.taskService.UpdateTask(c.Request.Context(), uint(id), input)
+	loc, err := parseTimezone(req.Timezone)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.StartDate != nil {
+		if input.StartDate, err = parseTaskDate("start_date", *req.StartDate, loc, false); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		input.ClearStartDate = input.StartDate == nil
+	}
+	if req.DueDate != nil {
+		if input.DueDate, err = parseTaskDate("due_date", *req.DueDate, loc, true); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		input.ClearDueDate = input.DueDate == nil
+	}
+	input.CustomFields = req.CustomFields
+
+	task, err := dc.taskService.UpdateTask(c.Request.Context(), currentActor(c), uint(id), input)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// DeleteTask godoc
+// @Summary Delete a task
+// @Description Developer can archive a task along with its subtasks. Archived tasks keep their history and can be restored.
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/tasks/{id} [delete]
+func (dc *DevController) DeleteTask(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task ID"})
+		return
+	}
+
+	if err := dc.taskService.DeleteTask(c.Request.Context(), currentActor(c), uint(id)); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RestoreTask godoc
+// @Summary Restore a deleted task
+// @Description Developer can restore an archived task and the subtasks archived with it. The task's project and parent task must not be archived.
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Success 200 {object} models.Task
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/tasks/{id}/restore [post]
+func (dc *DevController) RestoreTask(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task ID"})
+		return
+	}
+
+	task, err := dc.taskService.RestoreTask(c.Request.Context(), currentActor(c), uint(id))
+	if err != nil {
+		respondServiceError(c, err)
 		return
 	}
 
@@ -160,14 +309,36 @@ func (dc *DevController) UpdateTask(c *gin.Context) {
 
 // ListProjectTasks godoc
 // @Summary List tasks in a project
-// @Description Developer can view all tasks in a specific project
+// @Description Developer can page through the tasks of a project, with optional filters and ordering
 // @Tags developer
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Project ID"
-// @Success 200 {array} models.Task
+// @Param status query string false "Comma-separated statuses (TODO, IN_PROGRESS, DONE)"
+// @Param priority query string false "Comma-separated priorities (LOW, MEDIUM, HIGH)"
+// @Param assignee_id query string false "Assignee user ID, or none for unassigned tasks"
+// @Param q query string false "Case-insensitive text to find in the title or description"
+// @Param top_level query bool false "Only tasks that are not subtasks"
+// @Param label query string false "Comma-separated label IDs"
+// @Param label_mode query string false "any (default) to match tasks with any of the labels, all to require every label"
+// @Param cf.key query string false "Comma-separated values of the custom field with this key; dates are YYYY-MM-DD"
+// @Param created_after query string false "Only tasks created at or after this RFC3339 time"
+// @Param created_before query string false "Only tasks created before this RFC3339 time"
+// @Param updated_after query string false "Only tasks updated at or after this RFC3339 time"
+// @Param updated_before query string false "Only tasks updated before this RFC3339 time"
+// @Param due_after query string false "Only tasks due at or after this RFC3339 time"
+// @Param due_before query string false "Only tasks due before this RFC3339 time"
+// @Param due query string false "overdue, today or this_week (weeks start on Monday)"
+// @Param tz query string false "IANA time zone for due=today and due=this_week (default UTC)"
+// @Param sort query string false "Sort by created_at (default), updated_at, title, priority, status or cf.<key> for a custom field"
+// @Param order query string false "asc (default) or desc"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Success 200 {object} services.TaskPage
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/dev/projects/{id}/tasks [get]
 func (dc *DevController) ListProjectTasks(c *gin.Context) {
@@ -177,13 +348,75 @@ func (dc *DevController) ListProjectTasks(c *gin.Context) {
 		return
 	}
 
-	tasks, err := dc.taskService.ListProjectTasks(c.Request.Context(), uint(projectID))
+	query, err := parseTaskQuery(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := dc.taskService.ListProjectTasks(c.Request.Context(), currentActor(c), uint(projectID), query)
+	if err != nil {
+		respondServiceError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, tasks)
+	c.JSON(http.StatusOK, page)
+}
+
+// ListMyTasks godoc
+// @Summary List my tasks
+// @Description Any authenticated user can page through the tasks assigned to them across every workspace they belong to
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Comma-separated statuses (TODO, IN_PROGRESS, DONE)"
+// @Param priority query string false "Comma-separated priorities (LOW, MEDIUM, HIGH)"
+// @Param q query string false "Case-insensitive text to find in the title or description"
+// @Param top_level query bool false "Only tasks that are not subtasks"
+// @Param label query string false "Comma-separated label IDs"
+// @Param label_mode query string false "any (default) to match tasks with any of the labels, all to require every label"
+// @Param created_after query string false "Only tasks created at or after this RFC3339 time"
+// @Param created_before query string false "Only tasks created before this RFC3339 time"
+// @Param updated_after query string false "Only tasks updated at or after this RFC3339 time"
+// @Param updated_before query string false "Only tasks updated before this RFC3339 time"
+// @Param due_after query string false "Only tasks due at or after this RFC3339 time"
+// @Param due_before query string false "Only tasks due before this RFC3339 time"
+// @Param due query string false "overdue, today or this_week (weeks start on Monday)"
+// @Param tz query string false "IANA time zone for due=today and due=this_week (default UTC)"
+// @Param sort query string false "Sort by created_at (default), updated_at, title, priority or status"
+// @Param order query string false "asc (default) or desc"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Param group_by query string false "project to include per-project task counts"
+// @Success 200 {object} services.MyTasksPage
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/me/tasks [get]
+func (dc *DevController) ListMyTasks(c *gin.Context) {
+	query, err := parseTaskQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var groupByProject bool
+	switch c.Query("group_by") {
+	case "":
+	case "project":
+		groupByProject = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group_by: expected project"})
+		return
+	}
+
+	page, err := dc.taskService.ListMyTasks(c.Request.Context(), currentActor(c), query, groupByProject)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
 }
 
 // GetProject godoc
@@ -196,6 +429,7 @@ func (dc *DevController) ListProjectTasks(c *gin.Context) {
 // @Param id path int true "Project ID"
 // @Success 200 {object} models.Project
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Router /api/dev/projects/{id} [get]
 func (dc *DevController) GetProject(c *gin.Context) {
@@ -205,11 +439,339 @@ func (dc *DevController) GetProject(c *gin.Context) {
 		return
 	}
 
-	project, err := dc.projectService.GetProject(c.Request.Context(), uint(id))
+	project, err := dc.projectService.GetProject(c.Request.Context(), currentActor(c), uint(id))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		respondServiceError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, project)
 }
+
+// GetWorkflow godoc
+// @Summary Get a project's workflow
+// @Description Developer can view the statuses of a project and the transitions allowed between them
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Success 200 {object} models.Workflow
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/projects/{id}/workflow [get]
+func (dc *DevController) GetWorkflow(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	workflow, err := dc.workflowService.GetWorkflow(c.Request.Context(), currentActor(c), uint(id))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workflow)
+}
+
+// GetTaskHistory godoc
+// @Summary Get task history
+// @Description Developer can view every recorded change to a task, newest first
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Success 200 {array} models.TaskHistory
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/tasks/{id}/history [get]
+func (dc *DevController) GetTaskHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task ID"})
+		return
+	}
+
+	history, err := dc.historyService.ListTaskHistory(c.Request.Context(), currentActor(c), uint(id))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// ListProjectActivity godoc
+// @Summary List project activity
+// @Description Developer can view a paginated feed of task changes in a project
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Param user_id query int false "Only changes made by this user"
+// @Param change_type query string false "Only changes of this type (CREATE, UPDATE, ASSIGN, DELETE, RESTORE, OVERDUE)"
+// @Param since query string false "Only changes at or after this RFC3339 time"
+// @Param until query string false "Only changes before this RFC3339 time"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Success 200 {object} services.ActivityPage
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/projects/{id}/activity [get]
+func (dc *DevController) ListProjectActivity(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	query, err := parseActivityQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := dc.historyService.ListProjectActivity(c.Request.Context(), currentActor(c), uint(projectID), query)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// ListMyWorkspaces godoc
+// @Summary List my workspaces
+// @Description Any authenticated user can view every workspace they are a member of
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Workspace
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/workspaces [get]
+func (dc *DevController) ListMyWorkspaces(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	workspaces, err := dc.workspaceService.ListUserWorkspaces(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, workspaces)
+}
+
+// parseActivityQuery reads the activity feed filters shared by the project and
+// workspace feeds from the query string.
+func parseActivityQuery(c *gin.Context) (services.ActivityQuery, error) {
+	var query services.ActivityQuery
+
+	if v := c.Query("user_id"); v != "" {
+		userID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return query, errors.New("invalid user_id")
+		}
+		query.UserID = uint(userID)
+	}
+	query.ChangeType = c.Query("change_type")
+	if v := c.Query("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return query, errors.New("invalid since: expected RFC3339 timestamp")
+		}
+		query.Since = &since
+	}
+	if v := c.Query("until"); v != "" {
+		until, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return query, errors.New("invalid until: expected RFC3339 timestamp")
+		}
+		query.Until = &until
+	}
+	page, pageSize, err := parsePage(c)
+	if err != nil {
+		return query, err
+	}
+	query.Page = page
+	query.PageSize = pageSize
+
+	return query, nil
+}
+
+// parseTaskQuery reads the task listing filters, ordering and cursor from the
+// query string.
+func parseTaskQuery(c *gin.Context) (services.TaskQuery, error) {
+	var query services.TaskQuery
+
+	for _, v := range splitList(c.Query("status")) {
+		query.Statuses = append(query.Statuses, models.TaskStatus(strings.ToUpper(v)))
+	}
+	for _, v := range splitList(c.Query("priority")) {
+		query.Priorities = append(query.Priorities, models.TaskPriority(strings.ToUpper(v)))
+	}
+	if v := c.Query("assignee_id"); v == "none" {
+		query.Unassigned = true
+	} else if v != "" {
+		assigneeID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return query, errors.New("invalid assignee_id")
+		}
+		query.AssigneeID = uint(assigneeID)
+	}
+	query.Search = c.Query("q")
+	for _, v := range splitList(c.Query("label")) {
+		labelID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return query, errors.New("invalid label: expected comma-separated label IDs")
+		}
+		query.LabelIDs = append(query.LabelIDs, uint(labelID))
+	}
+	switch c.DefaultQuery("label_mode", "any") {
+	case "any":
+	case "all":
+		query.AllLabels = true
+	default:
+		return query, errors.New("invalid label_mode: expected any or all")
+	}
+	if v := c.Query("top_level"); v != "" {
+		topLevel, err := strconv.ParseBool(v)
+		if err != nil {
+			return query, errors.New("invalid top_level: expected true or false")
+		}
+		query.TopLevel = topLevel
+	}
+	for param, values := range c.Request.URL.Query() {
+		key, ok := strings.CutPrefix(param, "cf.")
+		if !ok {
+			continue
+		}
+		if query.CustomFields == nil {
+			query.CustomFields = map[string][]string{}
+		}
+		for _, v := range values {
+			query.CustomFields[key] = append(query.CustomFields[key], splitList(v)...)
+		}
+	}
+
+	ranges := []struct {
+		param string
+		dest  **time.Time
+	}{
+		{"created_after", &query.CreatedAfter},
+		{"created_before", &query.CreatedBefore},
+		{"updated_after", &query.UpdatedAfter},
+		{"updated_before", &query.UpdatedBefore},
+		{"due_after", &query.DueAfter},
+		{"due_before", &query.DueBefore},
+	}
+	for _, r := range ranges {
+		if v := c.Query(r.param); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return query, fmt.Errorf("invalid %s: expected RFC3339 timestamp", r.param)
+			}
+			*r.dest = &t
+		}
+	}
+
+	query.Due = c.Query("due")
+	loc, err := parseTimezone(c.Query("tz"))
+	if err != nil {
+		return query, err
+	}
+	query.Location = loc
+
+	query.Sort = c.Query("sort")
+	switch c.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		return query, errors.New("invalid order: expected asc or desc")
+	}
+	query.Cursor = c.Query("cursor")
+	if v := c.Query("page_size"); v != "" {
+		pageSize, err := strconv.Atoi(v)
+		if err != nil {
+			return query, errors.New("invalid page_size")
+		}
+		query.PageSize = pageSize
+	}
+
+	return query, nil
+}
+
+// parseTimezone loads an IANA time zone, defaulting to UTC.
+func parseTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q", name)
+	}
+	return loc, nil
+}
+
+// parseTaskDate reads an RFC3339 timestamp or a YYYY-MM-DD date in loc. A bare
+// date means the start of that day, or its last second when endOfDay is set,
+// so a task due on a date stays on time through the whole day. An empty value
+// yields nil.
+func parseTaskDate(field, value string, loc *time.Location, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: expected RFC3339 timestamp or YYYY-MM-DD date", field)
+	}
+	if endOfDay {
+		day = day.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	return &day, nil
+}
+
+// splitList splits a comma-separated query value, dropping empty entries.
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parsePage reads the optional page and page_size query parameters. Missing
+// values are returned as zero and defaulted by the services.
+func parsePage(c *gin.Context) (int, int, error) {
+	var page, pageSize int
+	var err error
+
+	if v := c.Query("page"); v != "" {
+		if page, err = strconv.Atoi(v); err != nil {
+			return 0, 0, errors.New("invalid page")
+		}
+	}
+	if v := c.Query("page_size"); v != "" {
+		if pageSize, err = strconv.Atoi(v); err != nil {
+			return 0, 0, errors.New("invalid page_size")
+		}
+	}
+
+	return page, pageSize, nil
+}
@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// respondServiceError writes err as a JSON error response, choosing the status
+// code from the services sentinel error it wraps.
+func respondServiceError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrUnauthenticated):
+		status = http.StatusUnauthorized
+	case errors.Is(err, services.ErrForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, services.ErrInvalidInput):
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrConflict):
+		status = http.StatusConflict
+	case errors.Is(err, services.ErrUnprocessable):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, services.ErrTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	}
+
+	var validationErr *services.ValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(status, gin.H{
+			"error":   err.Error(),
+			"field":   validationErr.Field,
+			"allowed": validationErr.Allowed,
+		})
+		return
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
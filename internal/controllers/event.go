@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/services"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/events"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// eventHeartbeat is how often an idle stream sends a comment to keep
+	// proxies from closing it. Access is re-checked at the same time.
+	eventHeartbeat = 15 * time.Second
+	// eventRetry tells clients how long to wait before reconnecting.
+	eventRetry = 3 * time.Second
+)
+
+type EventController struct {
+	eventService *services.EventService
+}
+
+func NewEventController(eventService *services.EventService) *EventController {
+	return &EventController{eventService: eventService}
+}
+
+// StreamProjectEvents godoc
+// @Summary Stream project task events
+// @Description Server-Sent Events stream of task.created, task.updated, task.assigned, task.deleted and task.restored events in a project, each carrying the task as JSON. Reconnecting with Last-Event-ID (or last_event_id) replays missed events; a reset event means some were lost and the client should reload. Browsers that cannot set headers may pass the token as access_token. The stream ends when the access token expires.
+// @Tags developer
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Param Last-Event-ID header string false "ID of the last event received"
+// @Param last_event_id query string false "ID of the last event received"
+// @Param access_token query string false "Access token, for clients that cannot set headers"
+// @Success 200 {string} string "event stream"
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/projects/{id}/events [get]
+func (ec *EventController) StreamProjectEvents(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+	lastEvent := c.GetHeader("Last-Event-ID")
+	if lastEvent == "" {
+		lastEvent = c.Query("last_event_id")
+	}
+	var lastEventID uint64
+	if lastEvent != "" {
+		if lastEventID, err = strconv.ParseUint(lastEvent, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid last event ID"})
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	actor := currentActor(c)
+	sub, missed, resumed, err := ec.eventService.Subscribe(ctx, actor, uint(projectID), lastEventID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	defer sub.Close()
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", eventRetry.Milliseconds())
+	if !resumed {
+		fmt.Fprintf(c.Writer, "id: %d\nevent: reset\ndata: {}\n\n", sub.Start)
+	}
+	for _, event := range missed {
+		writeEvent(c.Writer, event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+	expiry := time.NewTimer(time.Until(c.GetTime("token_expires_at")))
+	defer expiry.Stop()
+	tokenID := c.GetString("token_id")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-expiry.C:
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				// Dropped for falling behind; the client reconnects and
+				// resumes from the last event it got.
+				return
+			}
+			writeEvent(c.Writer, event)
+		case <-heartbeat.C:
+			if err := ec.eventService.Authorize(ctx, actor, tokenID, uint(projectID)); err != nil {
+				return
+			}
+			io.WriteString(c.Writer, ": heartbeat\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+// writeEvent writes an event in the Server-Sent Events format. Its data is
+// compact JSON, so it always fits on a single data line.
+func writeEvent(w io.Writer, event events.Event) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+}
@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type LabelRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Color string `json:"color"` // #rrggbb
+}
+
+// ListLabels godoc
+// @Summary List workspace labels
+// @Description Workspace members can list the labels available in a workspace
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param workspace_id path int true "Workspace ID"
+// @Success 200 {array} models.Label
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/workspaces/{workspace_id}/labels [get]
+func (dc *DevController) ListLabels(c *gin.Context) {
+	workspaceID, err := strconv.ParseUint(c.Param("workspace_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+		return
+	}
+
+	labels, err := dc.labelService.ListLabels(c.Request.Context(), currentActor(c), uint(workspaceID))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, labels)
+}
+
+// AttachLabel godoc
+// @Summary Attach a label to a task
+// @Description Developer can put a label from the task's workspace on a task
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param label_id path int true "Label ID"
+// @Success 200 {object} models.Task
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/tasks/{id}/labels/{label_id} [put]
+func (dc *DevController) AttachLabel(c *gin.Context) {
+	dc.changeTaskLabel(c, dc.labelService.AttachLabel)
+}
+
+// DetachLabel godoc
+// @Summary Detach a label from a task
+// @Description Developer can take a label off a task
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param label_id path int true "Label ID"
+// @Success 200 {object} models.Task
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/tasks/{id}/labels/{label_id} [delete]
+func (dc *DevController) DetachLabel(c *gin.Context) {
+	dc.changeTaskLabel(c, dc.labelService.DetachLabel)
+}
+
+func (dc *DevController) changeTaskLabel(c *gin.Context, change func(ctx context.Context, actor services.Actor, taskID, labelID uint) (*models.Task, error)) {
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task ID"})
+		return
+	}
+	labelID, err := strconv.ParseUint(c.Param("label_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid label ID"})
+		return
+	}
+
+	task, err := change(c.Request.Context(), currentActor(c), uint(taskID), uint(labelID))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// CreateLabel godoc
+// @Summary Create a label
+// @Description Workspace managers can add a label to the workspace's catalog. Names are unique per workspace, ignoring case.
+// @Tags manager
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param workspace_id path int true "Workspace ID"
+// @Param request body LabelRequest true "Label details"
+// @Success 201 {object} models.Label
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/manager/workspaces/{workspace_id}/labels [post]
+func (mc *ManagerController) CreateLabel(c *gin.Context) {
+	workspaceID, err := strconv.ParseUint(c.Param("workspace_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+		return
+	}
+
+	var req LabelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	label, err := mc.labelService.CreateLabel(c.Request.Context(), currentActor(c), uint(workspaceID), services.LabelInput{
+		Name:  req.Name,
+		Color: req.Color,
+	})
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, label)
+}
+
+// UpdateLabel godoc
+// @Summary Update a label
+// @Description Workspace managers can rename a label or change its color
+// @Tags manager
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Label ID"
+// @Param request body LabelRequest true "Label details"
+// @Success 200 {object} models.Label
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/manager/labels/{id} [put]
+func (mc *ManagerController) UpdateLabel(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid label ID"})
+		return
+	}
+
+	var req LabelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	label, err := mc.labelService.UpdateLabel(c.Request.Context(), currentActor(c), uint(id), services.LabelInput{
+		Name:  req.Name,
+		Color: req.Color,
+	})
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, label)
+}
+
+// DeleteLabel godoc
+// @Summary Delete a label
+// @Description Workspace managers can remove a label from the catalog; it is taken off every task
+// @Tags manager
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Label ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/manager/labels/{id} [delete]
+func (mc *ManagerController) DeleteLabel(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid label ID"})
+		return
+	}
+
+	if err := mc.labelService.DeleteLabel(c.Request.Context(), currentActor(c), uint(id)); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
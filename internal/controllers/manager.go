@@ -4,25 +4,41 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/services"
 	"github.com/gin-gonic/gin"
 )
 
 type ManagerController struct {
-	workspaceService *services.WorkspaceService
-	projectService   *services.ProjectService
-	taskService      *services.TaskService
+	workspaceService   *services.WorkspaceService
+	projectService     *services.ProjectService
+	taskService        *services.TaskService
+	historyService     *services.HistoryService
+	workflowService    *services.WorkflowService
+	labelService       *services.LabelService
+	customFieldService *services.CustomFieldService
+	webhookService     *services.WebhookService
 }
 
 func NewManagerController(
 	workspaceService *services.WorkspaceService,
 	projectService *services.ProjectService,
 	taskService *services.TaskService,
+	historyService *services.HistoryService,
+	workflowService *services.WorkflowService,
+	labelService *services.LabelService,
+	customFieldService *services.CustomFieldService,
+	webhookService *services.WebhookService,
 ) *ManagerController {
 	return &ManagerController{
-		workspaceService: workspaceService,
-		projectService:   projectService,
-		taskService:      taskService,
+		workspaceService:   workspaceService,
+		projectService:     projectService,
+		taskService:        taskService,
+		historyService:     historyService,
+		workflowService:    workflowService,
+		labelService:       labelService,
+		customFieldService: customFieldService,
+		webhookService:     webhookService,
 	}
 }
 
@@ -30,15 +46,51 @@ type CreateWorkspaceRequest struct {
 	Name string `json:"name" binding:"required"`
 }
 
+type UpdateWorkspaceRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
 type CreateProjectRequest struct {
 	Name        string `json:"name" binding:"required"`
 	WorkspaceID uint   `json:"workspace_id" binding:"required"`
 }
 
+type UpdateProjectRequest struct {
+	Name string `json:"name" binding:"required"`
+	// EnforceDependencies keeps blocked tasks from moving to IN_PROGRESS.
+	EnforceDependencies *bool `json:"enforce_dependencies"`
+}
+
+type UpdateWorkflowRequest struct {
+	Statuses    []WorkflowStatusRequest     `json:"statuses" binding:"required"`
+	Transitions []WorkflowTransitionRequest `json:"transitions"`
+}
+
+type WorkflowStatusRequest struct {
+	Name    models.TaskStatus `json:"name" binding:"required"`
+	Initial bool              `json:"initial"`
+	Done    bool              `json:"done"`
+}
+
+type WorkflowTransitionRequest struct {
+	From    models.TaskStatus    `json:"from" binding:"required"`
+	To      models.TaskStatus    `json:"to" binding:"required"`
+	MinRole models.WorkspaceRole `json:"min_role"`
+}
+
 type AssignTaskRequest struct {
 	AssigneeID uint `json:"assignee_id" binding:"required"`
 }
 
+type InviteMemberRequest struct {
+	Email string               `json:"email" binding:"required,email"`
+	Role  models.WorkspaceRole `json:"role" binding:"required"`
+}
+
+type UpdateMemberRoleRequest struct {
+	Role models.WorkspaceRole `json:"role" binding:"required"`
+}
+
 // CreateWorkspace godoc
 // @Summary Create a new workspace
 // @Description Manager/Admin can create a new workspace (team)
@@ -81,6 +133,8 @@ func (mc *ManagerController) CreateWorkspace(c *gin.Context) {
 // @Success 201 {object} models.Project
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/manager/projects [post]
 func (mc *ManagerController) CreateProject(c *gin.Context) {
@@ -90,9 +144,9 @@ func (mc *ManagerController) CreateProject(c *gin.Context) {
 		return
 	}
 
-	project, err := mc.projectService.CreateProject(c.Request.Context(), req.Name, req.WorkspaceID)
+	project, err := mc.projectService.CreateProject(c.Request.Context(), currentActor(c), req.Name, req.WorkspaceID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondServiceError(c, err)
 		return
 	}
 
@@ -111,6 +165,7 @@ func (mc *ManagerController) CreateProject(c *gin.Context) {
 // @Success 200 {object} models.Task
 // @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/manager/tasks/{id}/assign [put]
@@ -127,9 +182,9 @@ func (mc *ManagerController) AssignTask(c *gin.Context) {
 		return
 	}
 
-	task, err := mc.taskService.AssignTask(c.Request.Context(), uint(id), req.AssigneeID)
+	task, err := mc.taskService.AssignTask(c.Request.Context(), currentActor(c), uint(id), req.AssigneeID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondServiceError(c, err)
 		return
 	}
 
@@ -146,6 +201,8 @@ func (mc *ManagerController) AssignTask(c *gin.Context) {
 // @Param workspace_id path int true "Workspace ID"
 // @Success 200 {array} models.Project
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/manager/workspaces/{workspace_id}/projects [get]
 func (mc *ManagerController) ListWorkspaceProjects(c *gin.Context) {
@@ -155,11 +212,483 @@ func (mc *ManagerController) ListWorkspaceProjects(c *gin.Context) {
 		return
 	}
 
-	projects, err := mc.projectService.ListWorkspaceProjects(c.Request.Context(), uint(workspaceID))
+	projects, err := mc.projectService.ListWorkspaceProjects(c.Request.Context(), currentActor(c), uint(workspaceID))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondServiceError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, projects)
 }
+
+// ListWorkspaceActivity godoc
+// @Summary List workspace activity
+// @Description Manager/Admin can view a paginated feed of task changes across every project in a workspace
+// @Tags manager
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param workspace_id path int true "Workspace ID"
+// @Param user_id query int false "Only changes made by this user"
+// @Param change_type query string false "Only changes of this type (CREATE, UPDATE, ASSIGN, DELETE, RESTORE, OVERDUE)"
+// @Param since query string false "Only changes at or after this RFC3339 time"
+// @Param until query string false "Only changes before this RFC3339 time"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Success 200 {object} services.ActivityPage
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/manager/workspaces/{workspace_id}/activity [get]
+func (mc *ManagerController) ListWorkspaceActivity(c *gin.Context) {
+	workspaceID, err := strconv.ParseUint(c.Param("workspace_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+		return
+	}
+
+	query, err := parseActivityQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := mc.historyService.ListWorkspaceActivity(c.Request.Context(), currentActor(c), uint(workspaceID), query)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// InviteMember godoc
+// @Summary Invite a user to a workspace
+// @Description Manager/Admin can add a registered user to a workspace with a workspace role (manager, dev or viewer)
+// @Tags manager
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param workspace_id path int true "Workspace ID"
+// @Param request body InviteMemberRequest true "Member details"
+// @Success 201 {object} models.WorkspaceMember
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/manager/workspaces/{workspace_id}/members [post]
+func (mc *ManagerController) InviteMember(c *gin.Context) {
+	workspaceID, err := strconv.ParseUint(c.Param("workspace_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+		return
+	}
+
+	var req InviteMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := mc.workspaceService.InviteMember(c.Request.Context(), currentActor(c), uint(workspaceID), req.Email, req.Role)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, member)
+}
+
+// ListMembers godoc
+// @Summary List workspace members
+// @Description Manager/Admin can view the members of a workspace and their roles
+// @Tags manager
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param workspace_id path int true "Workspace ID"
+// @Success 200 {array} models.WorkspaceMember
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/manager/workspaces/{workspace_id}/members [get]
+func (mc *ManagerController) ListMembers(c *gin.Context) {
+	workspaceID, err := strconv.ParseUint(c.Param("workspace_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+		return
+	}
+
+	members, err := mc.workspaceService.ListMembers(c.Request.Context(), currentActor(c), uint(workspaceID))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// UpdateMemberRole godoc
+// @Summary Change a member's workspace role
+// @Description Manager/Admin can change the workspace role of a member. The owner's role cannot be changed.
+// @Tags manager
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param workspace_id path int true "Workspace ID"
+// @Param user_id path int true "User ID"
+// @Param request body UpdateMemberRoleRequest true "New role"
+// @Success 200 {object} models.WorkspaceMember
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/manager/workspaces/{workspace_id}/members/{user_id} [put]
+func (mc *ManagerController) UpdateMemberRole(c *gin.Context) {
+	workspaceID, err := strconv.ParseUint(c.Param("workspace_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+		return
+	}
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	var req UpdateMemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := mc.workspaceService.UpdateMemberRole(c.Request.Context(), currentActor(c), uint(workspaceID), uint(userID), req.Role)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveMember godoc
+// @Summary Remove a member from a workspace
+// @Description Manager/Admin can revoke a user's access to a workspace. The owner cannot be removed.
+// @Tags manager
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param workspace_id path int true "Workspace ID"
+// @Param user_id path int true "User ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/manager/workspaces/{workspace_id}/members/{user_id} [delete]
+func (mc *ManagerController) RemoveMember(c *gin.Context) {
+	workspaceID, err := strconv.ParseUint(c.Param("workspace_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+		return
+	}
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	if err := mc.workspaceService.RemoveMember(c.Request.Context(), currentActor(c), uint(workspaceID), uint(userID)); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// UpdateWorkspace godoc
+// @Summary Rename a workspace
+// @Description Workspace managers and owners can rename a workspace
+// @Tags manager
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param workspace_id path int true "Workspace ID"
+// @Param request body UpdateWorkspaceRequest true "Workspace details"
+// @Success 200 {object} models.Workspace
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/manager/workspaces/{workspace_id} [put]
+func (mc *ManagerController) UpdateWorkspace(c *gin.Context) {
+	workspaceID, err := strconv.ParseUint(c.Param("workspace_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+		return
+	}
+
+	var req UpdateWorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	workspace, err := mc.workspaceService.UpdateWorkspace(c.Request.Context(), currentActor(c), uint(workspaceID), req.Name)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workspace)
+}
+
+// ArchiveWorkspace godoc
+// @Summary Archive a workspace
+// @Description The workspace owner can archive a workspace. Its projects and their tasks are archived with it.
+// @Tags manager
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param workspace_id path int true "Workspace ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/manager/workspaces/{workspace_id} [delete]
+func (mc *ManagerController) ArchiveWorkspace(c *gin.Context) {
+	workspaceID, err := strconv.ParseUint(c.Param("workspace_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+		return
+	}
+
+	if err := mc.workspaceService.ArchiveWorkspace(c.Request.Context(), currentActor(c), uint(workspaceID)); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RestoreWorkspace godoc
+// @Summary Restore an archived workspace
+// @Description The workspace owner can restore an archived workspace together with the projects and tasks archived with it
+// @Tags manager
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param workspace_id path int true "Workspace ID"
+// @Success 200 {object} models.Workspace
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/manager/workspaces/{workspace_id}/restore [post]
+func (mc *ManagerController) RestoreWorkspace(c *gin.Context) {
+	workspaceID, err := strconv.ParseUint(c.Param("workspace_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace ID"})
+		return
+	}
+
+	workspace, err := mc.workspaceService.RestoreWorkspace(c.Request.Context(), currentActor(c), uint(workspaceID))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workspace)
+}
+
+// UpdateProject godoc
+// @Summary Update a project
+// @Description Workspace managers can rename a project and choose whether blocked tasks may move to IN_PROGRESS
+// @Tags manager
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Param request body UpdateProjectRequest true "Project details"
+// @Success 200 {object} models.Project
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/manager/projects/{id} [put]
+func (mc *ManagerController) UpdateProject(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	var req UpdateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project, err := mc.projectService.UpdateProject(c.Request.Context(), currentActor(c), uint(id), services.UpdateProjectInput{
+		Name:                req.Name,
+		EnforceDependencies: req.EnforceDependencies,
+	})
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, project)
+}
+
+// ArchiveProject godoc
+// @Summary Archive a project
+// @Description Workspace managers can archive a project. Its tasks are archived with it.
+// @Tags manager
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/manager/projects/{id} [delete]
+func (mc *ManagerController) ArchiveProject(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	if err := mc.projectService.ArchiveProject(c.Request.Context(), currentActor(c), uint(id)); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RestoreProject godoc
+// @Summary Restore an archived project
+// @Description Workspace managers can restore an archived project together with the tasks archived with it. The workspace must not be archived.
+// @Tags manager
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Success 200 {object} models.Project
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/manager/projects/{id}/restore [post]
+func (mc *ManagerController) RestoreProject(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	project, err := mc.projectService.RestoreProject(c.Request.Context(), currentActor(c), uint(id))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, project)
+}
+
+// UpdateWorkflow godoc
+// @Summary Define a project's workflow
+// @Description Workspace managers can replace the statuses and allowed transitions of a project. Statuses still used by the project's tasks must be kept.
+// @Tags manager
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Param request body UpdateWorkflowRequest true "Workflow definition"
+// @Success 200 {object} models.Workflow
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/manager/projects/{id}/workflow [put]
+func (mc *ManagerController) UpdateWorkflow(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	var req UpdateWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	input := services.WorkflowInput{}
+	for _, status := range req.Statuses {
+		input.Statuses = append(input.Statuses, services.WorkflowStatusInput{
+			Name:    status.Name,
+			Initial: status.Initial,
+			Done:    status.Done,
+		})
+	}
+	for _, transition := range req.Transitions {
+		input.Transitions = append(input.Transitions, services.WorkflowTransitionInput{
+			From:    transition.From,
+			To:      transition.To,
+			MinRole: transition.MinRole,
+		})
+	}
+
+	workflow, err := mc.workflowService.UpdateWorkflow(c.Request.Context(), currentActor(c), uint(id), input)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workflow)
+}
+
+// ResetWorkflow godoc
+// @Summary Reset a project's workflow
+// @Description Workspace managers can drop a project's custom workflow so it uses the default TODO, IN_PROGRESS, DONE workflow again
+// @Tags manager
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Project ID"
+// @Success 200 {object} models.Workflow
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/manager/projects/{id}/workflow [delete]
+func (mc *ManagerController) ResetWorkflow(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid project ID"})
+		return
+	}
+
+	workflow, err := mc.workflowService.ResetWorkflow(c.Request.Context(), currentActor(c), uint(id))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workflow)
+}
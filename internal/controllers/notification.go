@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationController struct {
+	notificationService *services.NotificationService
+}
+
+func NewNotificationController(notificationService *services.NotificationService) *NotificationController {
+	return &NotificationController{notificationService: notificationService}
+}
+
+// UpdateNotificationPreferencesRequest changes only the fields that are
+// present.
+type UpdateNotificationPreferencesRequest struct {
+	MutedKinds     []models.NotificationKind `json:"muted_kinds"` // assigned, mentioned, status_changed, task_updated, overdue
+	EmailKinds     []models.NotificationKind `json:"email_kinds"`
+	EmailFrequency models.EmailFrequency     `json:"email_frequency"`
+}
+
+// GetPreferences godoc
+// @Summary Get notification preferences
+// @Description Returns which kinds of notification the caller muted in their inbox, which ones they are emailed about and how often
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.NotificationPreference
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/profile/notifications [get]
+func (nc *NotificationController) GetPreferences(c *gin.Context) {
+	preference, err := nc.notificationService.GetPreferences(c.Request.Context(), currentActor(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preference)
+}
+
+// UpdatePreferences godoc
+// @Summary Update notification preferences
+// @Description Chooses which kinds of notification are left out of the caller's inbox and which ones they are emailed about, and whether emails are sent instantly, as a daily digest or not at all
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body UpdateNotificationPreferencesRequest true "Preferences to change"
+// @Success 200 {object} models.NotificationPreference
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/profile/notifications [put]
+func (nc *NotificationController) UpdatePreferences(c *gin.Context) {
+	var req UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preference, err := nc.notificationService.UpdatePreferences(c.Request.Context(), currentActor(c), services.NotificationPreferencesInput{
+		MutedKinds:     req.MutedKinds,
+		EmailKinds:     req.EmailKinds,
+		EmailFrequency: req.EmailFrequency,
+	})
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preference)
+}
+
+// ListNotifications godoc
+// @Summary List notifications
+// @Description Pages through the caller's in-app notifications, newest first
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param unread query bool false "Only unread notifications"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Success 200 {object} services.NotificationPage
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/notifications [get]
+func (nc *NotificationController) ListNotifications(c *gin.Context) {
+	page, pageSize, err := parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	unreadOnly := false
+	if value := c.Query("unread"); value != "" {
+		if unreadOnly, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid unread value"})
+			return
+		}
+	}
+
+	notifications, err := nc.notificationService.ListNotifications(c.Request.Context(), currentActor(c), unreadOnly, page, pageSize)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, notifications)
+}
+
+// CountUnreadNotifications godoc
+// @Summary Count unread notifications
+// @Description Returns how many of the caller's notifications are unread
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]int64
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/notifications/unread-count [get]
+func (nc *NotificationController) CountUnreadNotifications(c *gin.Context) {
+	count, err := nc.notificationService.CountUnread(c.Request.Context(), currentActor(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"count": count})
+}
+
+// MarkNotificationRead godoc
+// @Summary Mark a notification read
+// @Description Marks one of the caller's notifications read
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Notification ID"
+// @Success 200 {object} models.Notification
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/notifications/{id}/read [post]
+func (nc *NotificationController) MarkNotificationRead(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid notification ID"})
+		return
+	}
+
+	notification, err := nc.notificationService.MarkRead(c.Request.Context(), currentActor(c), uint(id))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, notification)
+}
+
+// MarkAllNotificationsRead godoc
+// @Summary Mark all notifications read
+// @Description Marks every unread notification of the caller read and returns how many there were
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]int64
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/notifications/read-all [post]
+func (nc *NotificationController) MarkAllNotificationsRead(c *gin.Context) {
+	marked, err := nc.notificationService.MarkAllRead(c.Request.Context(), currentActor(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"marked": marked})
+}
@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type SearchController struct {
+	searchService *services.SearchService
+}
+
+func NewSearchController(searchService *services.SearchService) *SearchController {
+	return &SearchController{searchService: searchService}
+}
+
+// Search godoc
+// @Summary Search tasks, comments and projects
+// @Description Full-text search over task titles and descriptions, comments and project names in the caller's workspaces. Results are ranked best match first; snippets are HTML-escaped with matches wrapped in <mark> tags. Supports quoted phrases, OR and -excluded words.
+// @Tags search
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param q query string true "Search text"
+// @Param type query string false "Comma-separated result types (task, comment, project); default all"
+// @Param workspace_id query int false "Only results in this workspace"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Success 200 {object} services.SearchPage
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/search [get]
+func (sc *SearchController) Search(c *gin.Context) {
+	page, pageSize, err := parsePage(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := services.SearchQuery{
+		Query:    c.Query("q"),
+		Types:    splitList(c.Query("type")),
+		Page:     page,
+		PageSize: pageSize,
+	}
+	if v := c.Query("workspace_id"); v != "" {
+		workspaceID, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid workspace_id"})
+			return
+		}
+		query.WorkspaceID = uint(workspaceID)
+	}
+
+	results, err := sc.searchService.Search(c.Request.Context(), currentActor(c), query)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MoveTaskRequest struct {
+	// ParentID is the new parent task; null makes the task a top-level task.
+	ParentID *uint `json:"parent_id"`
+}
+
+// ListSubtasks godoc
+// @Summary List subtasks
+// @Description Developer can page through the direct subtasks of a task. Accepts the same filters and ordering as the project task listing.
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param status query string false "Comma-separated statuses"
+// @Param priority query string false "Comma-separated priorities (LOW, MEDIUM, HIGH)"
+// @Param assignee_id query string false "Assignee user ID, or none for unassigned tasks"
+// @Param q query string false "Case-insensitive text to find in the title or description"
+// @Param sort query string false "Sort by created_at (default), updated_at, title, priority or status"
+// @Param order query string false "asc (default) or desc"
+// @Param cursor query string false "next_cursor from the previous page"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Success 200 {object} services.TaskPage
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/tasks/{id}/children [get]
+func (dc *DevController) ListSubtasks(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task ID"})
+		return
+	}
+
+	query, err := parseTaskQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := dc.taskService.ListSubtasks(c.Request.Context(), currentActor(c), uint(id), query)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// MoveTask godoc
+// @Summary Move a task under another parent
+// @Description Developer can make a task a subtask of another task in the same project, or a top-level task with a null parent_id. A task cannot be moved under itself or one of its subtasks, and an open task cannot be moved under a completed one.
+// @Tags developer
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Task ID"
+// @Param request body MoveTaskRequest true "New parent"
+// @Success 200 {object} models.Task
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/dev/tasks/{id}/parent [put]
+func (dc *DevController) MoveTask(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task ID"})
+		return
+	}
+
+	var req MoveTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := dc.taskService.MoveTask(c.Request.Context(), currentActor(c), uint(id), req.ParentID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Attachment is a file uploaded to a task. The contents live in the
+// configured storage backend under StorageKey.
+type Attachment struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	TaskID      uint      `json:"task_id" gorm:"not null;index"`
+	UploaderID  uint      `json:"uploader_id" gorm:"not null"`
+	Uploader    User      `json:"uploader" gorm:"foreignKey:UploaderID"`
+	FileName    string    `json:"file_name" gorm:"type:varchar(255);not null"`
+	ContentType string    `json:"content_type" gorm:"type:varchar(100);not null"`
+	Size        int64     `json:"size" gorm:"not null"`
+	StorageKey  string    `json:"-" gorm:"type:varchar(255);not null;uniqueIndex"`
+	CreatedAt   time.Time `json:"created_at"`
+}
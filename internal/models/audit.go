@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+const (
+	AuditActionUserRoleChanged   = "user.role_changed"
+	AuditActionAdminBootstrap    = "user.admin_bootstrapped"
+	AuditActionUserDisabled      = "user.disabled"
+	AuditActionUserEnabled       = "user.enabled"
+	AuditActionUserPasswordReset = "user.password_reset"
+	AuditActionUserDeleted       = "user.deleted"
+)
+
+// AuditLog records administrative actions that are not tied to a task.
+type AuditLog struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	ActorID       *uint     `json:"actor_id"` // Nil for actions performed by the system
+	Actor         *User     `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+	Action        string    `json:"action" gorm:"not null;index"`
+	TargetType    string    `json:"target_type" gorm:"not null"` // e.g., "user"
+	TargetID      uint      `json:"target_id" gorm:"not null"`
+	PreviousValue string    `json:"previous_value"`
+	NewValue      string    `json:"new_value"`
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TaskComment is a Markdown comment left on a task. Deleted comments are soft
+// deleted so their history stays intact.
+type TaskComment struct {
+	ID        uint             `json:"id" gorm:"primaryKey"`
+	TaskID    uint             `json:"task_id" gorm:"not null;index"`
+	AuthorID  uint             `json:"author_id" gorm:"not null"`
+	Author    User             `json:"author" gorm:"foreignKey:AuthorID"`
+	Body      string           `json:"body" gorm:"type:text;not null"` // Markdown
+	Mentions  []CommentMention `json:"mentions" gorm:"foreignKey:CommentID"`
+	EditedAt  *time.Time       `json:"edited_at"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+	DeletedAt gorm.DeletedAt   `json:"-" gorm:"index"`
+}
+
+// CommentMention records a workspace member @mentioned in a comment.
+type CommentMention struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CommentID uint      `json:"comment_id" gorm:"not null;uniqueIndex:idx_comment_mentions_comment_user"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_comment_mentions_comment_user;index"`
+	User      User      `json:"user" gorm:"foreignKey:UserID"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TaskCommentEdit keeps the body a comment had before an edit.
+type TaskCommentEdit struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	CommentID    uint      `json:"comment_id" gorm:"not null;index"`
+	EditorID     uint      `json:"editor_id" gorm:"not null"`
+	Editor       User      `json:"editor" gorm:"foreignKey:EditorID"`
+	PreviousBody string    `json:"previous_body" gorm:"type:text;not null"`
+	CreatedAt    time.Time `json:"created_at"`
+}
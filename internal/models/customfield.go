@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+type CustomFieldType string
+
+const (
+	CustomFieldText        CustomFieldType = "text"
+	CustomFieldNumber      CustomFieldType = "number"
+	CustomFieldDate        CustomFieldType = "date"
+	CustomFieldSelect      CustomFieldType = "select"
+	CustomFieldMultiSelect CustomFieldType = "multi_select"
+	CustomFieldUser        CustomFieldType = "user" // A member of the project's workspace
+)
+
+// CustomFieldTypes lists every supported custom field type.
+var CustomFieldTypes = []CustomFieldType{
+	CustomFieldText,
+	CustomFieldNumber,
+	CustomFieldDate,
+	CustomFieldSelect,
+	CustomFieldMultiSelect,
+	CustomFieldUser,
+}
+
+// IsValid reports whether t is a supported custom field type.
+func (t CustomFieldType) IsValid() bool {
+	for _, valid := range CustomFieldTypes {
+		if t == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// CustomField defines an extra piece of task metadata for one project, such as
+// story points or a customer name. Tasks refer to it by Key.
+type CustomField struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	ProjectID uint            `json:"project_id" gorm:"not null;uniqueIndex:idx_custom_fields_project_key"`
+	Key       string          `json:"key" gorm:"type:varchar(40);not null;uniqueIndex:idx_custom_fields_project_key"`
+	Name      string          `json:"name" gorm:"type:varchar(100);not null"`
+	Type      CustomFieldType `json:"type" gorm:"type:varchar(20);not null"`
+	Options   []string        `json:"options,omitempty" gorm:"type:jsonb;serializer:json"` // For select and multi_select
+	Required  bool            `json:"required" gorm:"not null;default:false"`
+	Position  int             `json:"position" gorm:"not null;default:0"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// CustomFieldValue holds a task's value for a custom field. Only the column
+// matching the field's type is set: select values are kept in Text and user
+// references in UserID.
+type CustomFieldValue struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	TaskID    uint       `json:"task_id" gorm:"not null;uniqueIndex:idx_custom_field_values_task_field"`
+	FieldID   uint       `json:"field_id" gorm:"not null;uniqueIndex:idx_custom_field_values_task_field;index:idx_custom_field_values_field_text,priority:1;index:idx_custom_field_values_field_number,priority:1;index:idx_custom_field_values_field_date,priority:1"`
+	Text      *string    `json:"text,omitempty" gorm:"column:text_value;index:idx_custom_field_values_field_text,priority:2"`
+	Number    *float64   `json:"number,omitempty" gorm:"column:number_value;index:idx_custom_field_values_field_number,priority:2"`
+	Date      *time.Time `json:"date,omitempty" gorm:"column:date_value;index:idx_custom_field_values_field_date,priority:2"`
+	Options   []string   `json:"options,omitempty" gorm:"column:options_value;type:jsonb;serializer:json"`
+	UserID    *uint      `json:"user_id,omitempty" gorm:"column:user_value"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// TaskDependency records that BlockingTask has to be completed before work on
+// BlockedTask can start. Both tasks belong to the same workspace, possibly to
+// different projects.
+type TaskDependency struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	BlockingTaskID uint      `json:"blocking_task_id" gorm:"not null;uniqueIndex:idx_task_dependencies_pair"`
+	BlockingTask   *Task     `json:"blocking_task,omitempty" gorm:"foreignKey:BlockingTaskID"`
+	BlockedTaskID  uint      `json:"blocked_task_id" gorm:"not null;uniqueIndex:idx_task_dependencies_pair;index"`
+	BlockedTask    *Task     `json:"blocked_task,omitempty" gorm:"foreignKey:BlockedTaskID"`
+	CreatedByID    uint      `json:"created_by_id" gorm:"not null"`
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -2,12 +2,35 @@ package models
 
 import "time"
 
+const (
+	HistoryChangeCreate  = "CREATE"
+	HistoryChangeUpdate  = "UPDATE"
+	HistoryChangeAssign  = "ASSIGN"
+	HistoryChangeDelete  = "DELETE"
+	HistoryChangeRestore = "RESTORE"
+	// Comment changes appear in the task activity feed as well.
+	HistoryChangeComment       = "COMMENT"
+	HistoryChangeCommentEdit   = "COMMENT_EDIT"
+	HistoryChangeCommentDelete = "COMMENT_DELETE"
+	// Dependency changes are recorded on both tasks of the link.
+	HistoryChangeDependencyAdd    = "DEPENDENCY_ADD"
+	HistoryChangeDependencyRemove = "DEPENDENCY_REMOVE"
+	HistoryChangeLabelAdd         = "LABEL_ADD"
+	HistoryChangeLabelRemove      = "LABEL_REMOVE"
+	HistoryChangeAttachmentAdd    = "ATTACHMENT_ADD"
+	HistoryChangeAttachmentRemove = "ATTACHMENT_REMOVE"
+	// HistoryChangeOverdue is recorded by the overdue monitor, not a user.
+	HistoryChangeOverdue = "OVERDUE"
+)
+
 type TaskHistory struct {
 	ID            uint      `json:"id" gorm:"primaryKey"`
-	TaskID        uint      `json:"task_id" gorm:"not null"`
-	UserID        uint      `json:"user_id" gorm:"not null"`     // Who made the change
+	TaskID        uint      `json:"task_id" gorm:"not null;index"`
+	Task          *Task     `json:"task,omitempty" gorm:"foreignKey:TaskID"`
+	UserID        *uint     `json:"user_id"` // Who made the change; nil for system changes
+	User          *User     `json:"user,omitempty" gorm:"foreignKey:UserID"`
 	ChangeType    string    `json:"change_type" gorm:"not null"` // e.g., "UPDATE", "CREATE", "DELETE"
 	PreviousValue string    `json:"previous_value"`              // JSON string or simple text
 	NewValue      string    `json:"new_value"`                   // JSON string or simple text
-	CreatedAt     time.Time `json:"created_at"`
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
 }
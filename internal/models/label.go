@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Label is an entry in a workspace's label catalog, e.g. "bug" or
+// "tech-debt". Names are unique per workspace regardless of case.
+type Label struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	WorkspaceID uint      `json:"workspace_id" gorm:"not null;index"`
+	Name        string    `json:"name" gorm:"type:varchar(50);not null"`
+	Color       string    `json:"color" gorm:"type:varchar(7);not null"` // #rrggbb
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
@@ -0,0 +1,97 @@
+package models
+
+import "time"
+
+type NotificationKind string
+
+const (
+	NotificationAssigned      NotificationKind = "assigned"       // A task was assigned to the user
+	NotificationMentioned     NotificationKind = "mentioned"      // The user was @mentioned in a comment
+	NotificationStatusChanged NotificationKind = "status_changed" // A task the user created or is assigned to changed status
+	NotificationTaskUpdated   NotificationKind = "task_updated"   // A task the user created or is assigned to was otherwise changed
+	NotificationOverdue       NotificationKind = "overdue"        // A task assigned to the user passed its due date
+)
+
+// NotificationKinds lists every kind of notification.
+var NotificationKinds = []NotificationKind{
+	NotificationAssigned,
+	NotificationMentioned,
+	NotificationStatusChanged,
+	NotificationTaskUpdated,
+	NotificationOverdue,
+}
+
+// DefaultEmailKinds are the kinds of notification users are emailed about
+// until they choose otherwise. Other task changes are too frequent to email.
+var DefaultEmailKinds = []NotificationKind{
+	NotificationAssigned,
+	NotificationMentioned,
+	NotificationStatusChanged,
+	NotificationOverdue,
+}
+
+// IsValid reports whether k is one of NotificationKinds.
+func (k NotificationKind) IsValid() bool {
+	for _, kind := range NotificationKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// EmailFrequency is how often a user is emailed about notifications.
+type EmailFrequency string
+
+const (
+	EmailInstant EmailFrequency = "instant" // One email per notification
+	EmailDaily   EmailFrequency = "daily"   // A single digest per day
+	EmailOff     EmailFrequency = "off"
+)
+
+// EmailFrequencies lists every email frequency.
+var EmailFrequencies = []EmailFrequency{EmailInstant, EmailDaily, EmailOff}
+
+// NotificationPreference holds how a user wants to be notified. Users without
+// one get every kind of notification in their inbox and are emailed about the
+// DefaultEmailKinds as they happen.
+type NotificationPreference struct {
+	UserID uint `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	// MutedKinds lists the kinds of notification left out of the user's inbox.
+	MutedKinds []NotificationKind `json:"muted_kinds" gorm:"type:jsonb;serializer:json"`
+	// EmailKinds lists the kinds of notification the user is emailed about.
+	EmailKinds     []NotificationKind `json:"email_kinds" gorm:"type:jsonb;serializer:json"`
+	EmailFrequency EmailFrequency     `json:"email_frequency" gorm:"type:varchar(10);not null"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+// Notification is an entry in a user's in-app inbox. Like digest items, it
+// keeps the text as it was when the notification happened.
+type Notification struct {
+	ID          uint             `json:"id" gorm:"primaryKey"`
+	UserID      uint             `json:"user_id" gorm:"not null;index:idx_notifications_user_read,priority:1"`
+	Kind        NotificationKind `json:"kind" gorm:"type:varchar(20);not null"`
+	ActorID     *uint            `json:"actor_id"` // Who caused it; nil for system changes
+	Actor       *User            `json:"actor,omitempty" gorm:"foreignKey:ActorID"`
+	TaskID      uint             `json:"task_id" gorm:"not null"`
+	TaskTitle   string           `json:"task_title" gorm:"not null"`
+	ProjectName string           `json:"project_name" gorm:"not null"`
+	Summary     string           `json:"summary" gorm:"type:text;not null"`
+	Detail      string           `json:"detail" gorm:"type:text"`
+	ReadAt      *time.Time       `json:"read_at" gorm:"index:idx_notifications_user_read,priority:2"` // Nil while unread
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+// EmailDigestItem is a notification waiting for the recipient's next daily
+// digest. It keeps the text as it was when the notification happened.
+type EmailDigestItem struct {
+	ID          uint             `json:"id" gorm:"primaryKey"`
+	UserID      uint             `json:"user_id" gorm:"not null;index"`
+	Kind        NotificationKind `json:"kind" gorm:"type:varchar(20);not null"`
+	TaskID      uint             `json:"task_id" gorm:"not null"`
+	TaskTitle   string           `json:"task_title" gorm:"not null"`
+	ProjectName string           `json:"project_name" gorm:"not null"`
+	Summary     string           `json:"summary" gorm:"type:text;not null"`
+	Detail      string           `json:"detail" gorm:"type:text"` // E.g. the comment that mentioned the user
+	CreatedAt   time.Time        `json:"created_at"`
+}
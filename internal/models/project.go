@@ -1,12 +1,19 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Project struct {
 	ID          uint      `json:"id" gorm:"primaryKey"`
 	Name        string    `json:"name" gorm:"not null"`
 	WorkspaceID uint      `json:"workspace_id" gorm:"not null"`
 	Workspace   Workspace `json:"workspace" gorm:"foreignKey:WorkspaceID"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	// EnforceDependencies keeps blocked tasks from being started.
+	EnforceDependencies bool           `json:"enforce_dependencies" gorm:"not null;default:false"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `json:"deleted_at" gorm:"index"` // Set while archived
 }
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type TaskStatus string
 type TaskPriority string
@@ -17,16 +21,50 @@ const (
 	TaskPriorityHigh   TaskPriority = "HIGH"
 )
 
+// TaskPriorities lists every valid priority, lowest first.
+var TaskPriorities = []TaskPriority{TaskPriorityLow, TaskPriorityMedium, TaskPriorityHigh}
+
+// IsValid reports whether p is one of TaskPriorities.
+func (p TaskPriority) IsValid() bool {
+	for _, priority := range TaskPriorities {
+		if p == priority {
+			return true
+		}
+	}
+	return false
+}
+
 type Task struct {
-	ID          uint         `json:"id" gorm:"primaryKey"`
-	Title       string       `json:"title" gorm:"not null"`
-	Description string       `json:"description"`
-	Status      TaskStatus   `json:"status" gorm:"type:varchar(20);default:'TODO'"`
-	Priority    TaskPriority `json:"priority" gorm:"type:varchar(20);default:'MEDIUM'"`
-	AssigneeID  *uint        `json:"assignee_id"` // Pointer to allow null
-	Assignee    *User        `json:"assignee" gorm:"foreignKey:AssigneeID"`
-	ProjectID   uint         `json:"project_id" gorm:"not null"`
-	Project     Project      `json:"project" gorm:"foreignKey:ProjectID"`
-	CreatedAt   time.Time    `json:"created_at"`
-	UpdatedAt   time.Time    `json:"updated_at"`
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Title       string         `json:"title" gorm:"not null"`
+	Description string         `json:"description"`
+	Status      TaskStatus     `json:"status" gorm:"type:varchar(20);default:'TODO'"`
+	Priority    TaskPriority   `json:"priority" gorm:"type:varchar(20);default:'MEDIUM'"`
+	AssigneeID  *uint          `json:"assignee_id" gorm:"index"` // Pointer to allow null
+	Assignee    *User          `json:"assignee" gorm:"foreignKey:AssigneeID"`
+	ProjectID   uint           `json:"project_id" gorm:"not null;index:idx_tasks_project_created,priority:1"`
+	Project     Project        `json:"project" gorm:"foreignKey:ProjectID"`
+	CreatedByID *uint          `json:"created_by_id"`          // Nil for tasks created before creators were recorded
+	ParentID    *uint          `json:"parent_id" gorm:"index"` // Set on subtasks
+	Labels      []Label        `json:"labels" gorm:"many2many:task_labels"`
+	StartDate   *time.Time     `json:"start_date"`
+	DueDate     *time.Time     `json:"due_date" gorm:"index"`
+	CompletedAt *time.Time     `json:"completed_at"` // Set while the task is in a done status
+	OverdueAt   *time.Time     `json:"overdue_at"`   // When the task was flagged for passing its due date
+	CreatedAt   time.Time      `json:"created_at" gorm:"index:idx_tasks_project_created,priority:2"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"` // Set while archived
+	Progress    *TaskProgress  `json:"progress,omitempty" gorm:"-"`
+	// Blocked is set while another live task this one depends on is open.
+	Blocked bool `json:"blocked" gorm:"-"`
+	// CustomFields maps the key of each of the project's custom fields to the
+	// task's value, or nil when it has none.
+	CustomFields map[string]interface{} `json:"custom_fields,omitempty" gorm:"-"`
+}
+
+// TaskProgress rolls up the completion of a task's subtasks at every depth.
+type TaskProgress struct {
+	Total     int64 `json:"total"`
+	Completed int64 `json:"completed"`
+	Percent   int   `json:"percent"`
 }
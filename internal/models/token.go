@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// RefreshToken is a server-side record of an issued refresh token. Tokens are
+// rotated on every use: the old token is revoked and points at its
+// replacement, and all tokens descending from one login share a FamilyID so a
+// replayed token can revoke the whole chain.
+type RefreshToken struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"not null;index"`
+	TokenHash    string     `json:"-" gorm:"type:char(64);not null;uniqueIndex"`
+	FamilyID     string     `json:"family_id" gorm:"type:varchar(64);not null;index"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	ReplacedByID *uint      `json:"replaced_by_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// RevokedToken lists an access token (by its jti claim) that must be rejected
+// before it expires. Rows can be purged once ExpiresAt has passed.
+type RevokedToken struct {
+	TokenID   string    `json:"token_id" gorm:"primaryKey;type:varchar(64)"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -11,11 +11,17 @@ const (
 )
 
 type User struct {
-	ID           uint      `json:"id" gorm:"primaryKey"`
-	Username     string    `json:"username" gorm:"unique;not null"`
-	Email        string    `json:"email" gorm:"unique;not null"`
-	PasswordHash string    `json:"-" gorm:"not null"`
-	Role         UserRole  `json:"role" gorm:"type:varchar(20);default:'dev'"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID                    uint       `json:"id" gorm:"primaryKey"`
+	Username              string     `json:"username" gorm:"unique;not null"`
+	Email                 string     `json:"email" gorm:"unique;not null"`
+	PasswordHash          string     `json:"-" gorm:"not null"`
+	Role                  UserRole   `json:"role" gorm:"type:varchar(20);default:'dev'"`
+	DisabledAt            *time.Time `json:"disabled_at"`                                           // Nil while the account is enabled
+	PasswordResetRequired bool       `json:"password_reset_required" gorm:"not null;default:false"` // Set when an admin forces a reset
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+}
+
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
 }
@@ -0,0 +1,88 @@
+package models
+
+import "time"
+
+// Event types published when tasks, projects and comments change.
+const (
+	EventTaskCreated     = "task.created"
+	EventTaskUpdated     = "task.updated"
+	EventTaskAssigned    = "task.assigned"
+	EventTaskDeleted     = "task.deleted"
+	EventTaskRestored    = "task.restored"
+	EventProjectCreated  = "project.created"
+	EventProjectUpdated  = "project.updated"
+	EventProjectArchived = "project.archived"
+	EventProjectRestored = "project.restored"
+	EventCommentCreated  = "comment.created"
+	EventCommentUpdated  = "comment.updated"
+	EventCommentDeleted  = "comment.deleted"
+)
+
+// WebhookEvents lists every event type a webhook can subscribe to.
+var WebhookEvents = []string{
+	EventTaskCreated,
+	EventTaskUpdated,
+	EventTaskAssigned,
+	EventTaskDeleted,
+	EventTaskRestored,
+	EventProjectCreated,
+	EventProjectUpdated,
+	EventProjectArchived,
+	EventProjectRestored,
+	EventCommentCreated,
+	EventCommentUpdated,
+	EventCommentDeleted,
+}
+
+// Webhook posts a workspace's events to an external URL, signed with Secret.
+type Webhook struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	WorkspaceID uint      `json:"workspace_id" gorm:"not null;index"`
+	URL         string    `json:"url" gorm:"type:varchar(2048);not null"`
+	Secret      string    `json:"-" gorm:"type:varchar(255);not null"`
+	Events      []string  `json:"events" gorm:"type:jsonb;serializer:json"`
+	Active      bool      `json:"active" gorm:"not null"`
+	CreatedByID uint      `json:"created_by_id" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Subscribes reports whether the webhook is active and wants event.
+func (w *Webhook) Subscribes(event string) bool {
+	if !w.Active {
+		return false
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed" // Gave up after the last retry
+)
+
+// WebhookDelivery is one event queued for a webhook, together with the outcome
+// of its latest attempt.
+type WebhookDelivery struct {
+	ID        uint                  `json:"id" gorm:"primaryKey"`
+	WebhookID uint                  `json:"webhook_id" gorm:"not null;index"`
+	Event     string                `json:"event" gorm:"type:varchar(40);not null"`
+	Payload   string                `json:"payload" gorm:"type:text;not null"` // JSON request body
+	Status    WebhookDeliveryStatus `json:"status" gorm:"type:varchar(20);not null;index:idx_webhook_deliveries_due,priority:1"`
+	Attempts  int                   `json:"attempts" gorm:"not null;default:0"`
+	// NextAttemptAt is when a pending delivery is tried next.
+	NextAttemptAt  *time.Time `json:"next_attempt_at" gorm:"index:idx_webhook_deliveries_due,priority:2"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at"`
+	ResponseStatus int        `json:"response_status"` // 0 if the last attempt got no response
+	ResponseBody   string     `json:"response_body"`   // Truncated
+	Error          string     `json:"error"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,123 @@
+package models
+
+// WorkflowStatus is one of the statuses tasks in a project may take. Statuses
+// are ordered by Position; new tasks start in the Initial one, and tasks in a
+// Done status count as completed.
+type WorkflowStatus struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	ProjectID uint       `json:"project_id" gorm:"not null;uniqueIndex:idx_workflow_statuses_project_name"`
+	Name      TaskStatus `json:"name" gorm:"type:varchar(20);not null;uniqueIndex:idx_workflow_statuses_project_name"`
+	Position  int        `json:"position" gorm:"not null"`
+	Initial   bool       `json:"initial" gorm:"not null;default:false"`
+	Done      bool       `json:"done" gorm:"not null;default:false"`
+}
+
+// WorkflowTransition allows tasks in a project to move from one status to
+// another. Only workspace members holding at least MinRole may perform it.
+type WorkflowTransition struct {
+	ID        uint          `json:"id" gorm:"primaryKey"`
+	ProjectID uint          `json:"project_id" gorm:"not null;uniqueIndex:idx_workflow_transitions_project_from_to"`
+	From      TaskStatus    `json:"from" gorm:"column:from_status;type:varchar(20);not null;uniqueIndex:idx_workflow_transitions_project_from_to"`
+	To        TaskStatus    `json:"to" gorm:"column:to_status;type:varchar(20);not null;uniqueIndex:idx_workflow_transitions_project_from_to"`
+	MinRole   WorkspaceRole `json:"min_role" gorm:"type:varchar(20);not null;default:'dev'"`
+}
+
+// Workflow is the complete set of statuses and transitions for a project.
+// Projects without a custom definition use DefaultWorkflow.
+type Workflow struct {
+	ProjectID   uint                 `json:"project_id"`
+	Custom      bool                 `json:"custom"`
+	Statuses    []WorkflowStatus     `json:"statuses"`
+	Transitions []WorkflowTransition `json:"transitions"`
+}
+
+// DefaultWorkflow is the built-in TODO, IN_PROGRESS, DONE workflow. Any member
+// with the dev role can move a task between any two of its statuses.
+func DefaultWorkflow(projectID uint) *Workflow {
+	statuses := []TaskStatus{TaskStatusTodo, TaskStatusInProgress, TaskStatusDone}
+
+	workflow := &Workflow{ProjectID: projectID}
+	for i, status := range statuses {
+		workflow.Statuses = append(workflow.Statuses, WorkflowStatus{
+			ProjectID: projectID,
+			Name:      status,
+			Position:  i,
+			Initial:   i == 0,
+			Done:      status == TaskStatusDone,
+		})
+		for _, to := range statuses {
+			if to != status {
+				workflow.Transitions = append(workflow.Transitions, WorkflowTransition{
+					ProjectID: projectID,
+					From:      status,
+					To:        to,
+					MinRole:   WorkspaceRoleDev,
+				})
+			}
+		}
+	}
+	return workflow
+}
+
+// InitialStatus returns the status new tasks start in.
+func (w *Workflow) InitialStatus() TaskStatus {
+	for _, status := range w.Statuses {
+		if status.Initial {
+			return status.Name
+		}
+	}
+	if len(w.Statuses) > 0 {
+		return w.Statuses[0].Name
+	}
+	return TaskStatusTodo
+}
+
+// HasStatus reports whether status is part of the workflow.
+func (w *Workflow) HasStatus(status TaskStatus) bool {
+	for _, s := range w.Statuses {
+		if s.Name == status {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDone reports whether tasks in status count as completed.
+func (w *Workflow) IsDone(status TaskStatus) bool {
+	for _, s := range w.Statuses {
+		if s.Name == status {
+			return s.Done
+		}
+	}
+	return false
+}
+
+// DoneStatuses returns the statuses that count as completed.
+func (w *Workflow) DoneStatuses() []TaskStatus {
+	var names []TaskStatus
+	for _, status := range w.Statuses {
+		if status.Done {
+			names = append(names, status.Name)
+		}
+	}
+	return names
+}
+
+// StatusNames returns the workflow's statuses in order.
+func (w *Workflow) StatusNames() []TaskStatus {
+	names := make([]TaskStatus, 0, len(w.Statuses))
+	for _, status := range w.Statuses {
+		names = append(names, status.Name)
+	}
+	return names
+}
+
+// Transition returns the transition from one status to another, if allowed.
+func (w *Workflow) Transition(from, to TaskStatus) (*WorkflowTransition, bool) {
+	for i := range w.Transitions {
+		if w.Transitions[i].From == from && w.Transitions[i].To == to {
+			return &w.Transitions[i], true
+		}
+	}
+	return nil, false
+}
@@ -1,12 +1,38 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WorkspaceRole string
+
+const (
+	WorkspaceRoleOwner   WorkspaceRole = "owner"
+	WorkspaceRoleManager WorkspaceRole = "manager"
+	WorkspaceRoleDev     WorkspaceRole = "dev"
+	WorkspaceRoleViewer  WorkspaceRole = "viewer"
+)
 
 type Workspace struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Name      string    `json:"name" gorm:"not null"`
-	OwnerID   uint      `json:"owner_id" gorm:"not null"`
-	Owner     User      `json:"owner" gorm:"foreignKey:OwnerID"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	Name      string         `json:"name" gorm:"not null"`
+	OwnerID   uint           `json:"owner_id" gorm:"not null"`
+	Owner     User           `json:"owner" gorm:"foreignKey:OwnerID"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"` // Set while archived
+}
+
+// WorkspaceMember grants a user access to a workspace with a workspace-scoped
+// role. The workspace owner always has a member row with WorkspaceRoleOwner.
+type WorkspaceMember struct {
+	ID          uint          `json:"id" gorm:"primaryKey"`
+	WorkspaceID uint          `json:"workspace_id" gorm:"not null;uniqueIndex:idx_workspace_members_workspace_user"`
+	UserID      uint          `json:"user_id" gorm:"not null;uniqueIndex:idx_workspace_members_workspace_user;index"`
+	User        User          `json:"user" gorm:"foreignKey:UserID"`
+	Role        WorkspaceRole `json:"role" gorm:"type:varchar(20);not null;default:'dev'"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
 }
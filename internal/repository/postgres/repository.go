@@ -2,12 +2,25 @@ package postgres
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// likeEscaper escapes the LIKE wildcards so user input is matched literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// containsPattern builds a lowercase LIKE pattern matching s anywhere.
+func containsPattern(s string) string {
+	return "%" + likeEscaper.Replace(strings.ToLower(s)) + "%"
+}
+
 type userRepository struct {
 	db *gorm.DB
 }
@@ -36,6 +49,47 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.
 	return &user, nil
 }
 
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Save(user).Error
+}
+
+func (r *userRepository) CountEnabledByRole(ctx context.Context, role models.UserRole) (int64, error) {
+	// Postgres cannot lock the rows behind an aggregate, so the IDs are
+	// selected FOR UPDATE and counted here.
+	var ids []uint
+	err := r.db.WithContext(ctx).Model(&models.User{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("role = ? AND disabled_at IS NULL", role).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(ids)), nil
+}
+
+func (r *userRepository) List(ctx context.Context, filter repository.UserFilter) ([]models.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.User{})
+	if filter.Search != "" {
+		pattern := containsPattern(filter.Search)
+		query = query.Where("LOWER(username) LIKE ? OR LOWER(email) LIKE ?", pattern, pattern)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	if err := query.Order("id").Limit(filter.Limit).Offset(filter.Offset).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
 type workspaceRepository struct {
 	db *gorm.DB
 }
@@ -56,16 +110,101 @@ func (r *workspaceRepository) GetByID(ctx context.Context, id uint) (*models.Wor
 	return &workspace, nil
 }
 
+func (r *workspaceRepository) GetIncludingArchived(ctx context.Context, id uint) (*models.Workspace, error) {
+	var workspace models.Workspace
+	if err := r.db.WithContext(ctx).Unscoped().Preload("Owner").First(&workspace, id).Error; err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+func (r *workspaceRepository) Update(ctx context.Context, workspace *models.Workspace) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(workspace).Error
+}
+
+func (r *workspaceRepository) Archive(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Workspace{}).
+		Where("id = ?", id).
+		Update("deleted_at", at).Error
+}
+
+func (r *workspaceRepository) Restore(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&models.Workspace{}).
+		Where("id = ?", id).
+		Update("deleted_at", nil).Error
+}
+
 func (r *workspaceRepository) ListByUserID(ctx context.Context, userID uint) ([]models.Workspace, error) {
 	var workspaces []models.Workspace
-	// Assuming logic: workspaces owned by user.
-	// In a real app, might also include workspaces where user is a member.
-	if err := r.db.WithContext(ctx).Where("owner_id = ?", userID).Find(&workspaces).Error; err != nil {
+	err := r.db.WithContext(ctx).
+		Joins("JOIN workspace_members ON workspace_members.workspace_id = workspaces.id").
+		Where("workspace_members.user_id = ?", userID).
+		Order("workspaces.id").
+		Find(&workspaces).Error
+	if err != nil {
+		return nil, err
+	}
+	return workspaces, nil
+}
+
+func (r *workspaceRepository) ListOwnedByUserID(ctx context.Context, userID uint) ([]models.Workspace, error) {
+	var workspaces []models.Workspace
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("owner_id = ?", userID).
+		Order("id").
+		Find(&workspaces).Error
+	if err != nil {
 		return nil, err
 	}
 	return workspaces, nil
 }
 
+type workspaceMemberRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkspaceMemberRepository(db *gorm.DB) repository.WorkspaceMemberRepository {
+	return &workspaceMemberRepository{db: db}
+}
+
+func (r *workspaceMemberRepository) Create(ctx context.Context, member *models.WorkspaceMember) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(member).Error
+}
+
+func (r *workspaceMemberRepository) Get(ctx context.Context, workspaceID, userID uint) (*models.WorkspaceMember, error) {
+	var member models.WorkspaceMember
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *workspaceMemberRepository) ListByWorkspaceID(ctx context.Context, workspaceID uint) ([]models.WorkspaceMember, error) {
+	var members []models.WorkspaceMember
+	if err := r.db.WithContext(ctx).Where("workspace_id = ?", workspaceID).Preload("User").Order("id").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (r *workspaceMemberRepository) Update(ctx context.Context, member *models.WorkspaceMember) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(member).Error
+}
+
+func (r *workspaceMemberRepository) Delete(ctx context.Context, workspaceID, userID uint) error {
+	return r.db.WithContext(ctx).
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		Delete(&models.WorkspaceMember{}).Error
+}
+
+func (r *workspaceMemberRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.WorkspaceMember{}).Error
+}
+
 type projectRepository struct {
 	db *gorm.DB
 }
@@ -86,6 +225,45 @@ func (r *projectRepository) GetByID(ctx context.Context, id uint) (*models.Proje
 	return &project, nil
 }
 
+func (r *projectRepository) GetIncludingArchived(ctx context.Context, id uint) (*models.Project, error) {
+	var project models.Project
+	err := r.db.WithContext(ctx).Unscoped().
+		Preload("Workspace", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		First(&project, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (r *projectRepository) Update(ctx context.Context, project *models.Project) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(project).Error
+}
+
+func (r *projectRepository) Archive(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Project{}).
+		Where("id = ?", id).
+		Update("deleted_at", at).Error
+}
+
+func (r *projectRepository) Restore(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&models.Project{}).
+		Where("id = ?", id).
+		Update("deleted_at", nil).Error
+}
+
+func (r *projectRepository) ArchiveByWorkspaceID(ctx context.Context, workspaceID uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Project{}).
+		Where("workspace_id = ?", workspaceID).
+		Update("deleted_at", at).Error
+}
+
+func (r *projectRepository) RestoreByWorkspaceID(ctx context.Context, workspaceID uint, archivedAt time.Time) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&models.Project{}).
+		Where("workspace_id = ? AND deleted_at = ?", workspaceID, archivedAt).
+		Update("deleted_at", nil).Error
+}
+
 func (r *projectRepository) ListByWorkspaceID(ctx context.Context, workspaceID uint) ([]models.Project, error) {
 	var projects []models.Project
 	if err := r.db.WithContext(ctx).Where("workspace_id = ?", workspaceID).Find(&projects).Error; err != nil {
@@ -108,80 +286,1392 @@ func (r *taskRepository) Create(ctx context.Context, task *models.Task) error {
 
 func (r *taskRepository) GetByID(ctx context.Context, id uint) (*models.Task, error) {
 	var task models.Task
-	if err := r.db.WithContext(ctx).Preload("Assignee").Preload("Project").First(&task, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Assignee").Preload("Project").Preload("Labels", orderLabels).First(&task, id).Error; err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *taskRepository) GetIncludingArchived(ctx context.Context, id uint) (*models.Task, error) {
+	var task models.Task
+	err := r.db.WithContext(ctx).Unscoped().
+		Preload("Assignee").
+		Preload("Project", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Labels", orderLabels).
+		First(&task, id).Error
+	if err != nil {
 		return nil, err
 	}
 	return &task, nil
 }
 
+func (r *taskRepository) Archive(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Task{}).
+		Where("id = ?", id).
+		Update("deleted_at", at).Error
+}
+
+func (r *taskRepository) Restore(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&models.Task{}).
+		Where("id = ?", id).
+		Update("deleted_at", nil).Error
+}
+
+func (r *taskRepository) ArchiveByProjectID(ctx context.Context, projectID uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Task{}).
+		Where("project_id = ?", projectID).
+		Update("deleted_at", at).Error
+}
+
+func (r *taskRepository) RestoreByProjectID(ctx context.Context, projectID uint, archivedAt time.Time) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&models.Task{}).
+		Where("project_id = ? AND deleted_at = ?", projectID, archivedAt).
+		Update("deleted_at", nil).Error
+}
+
+func (r *taskRepository) ArchiveByWorkspaceID(ctx context.Context, workspaceID uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Task{}).
+		Where("project_id IN (?)", r.db.Unscoped().Model(&models.Project{}).Select("id").Where("workspace_id = ?", workspaceID)).
+		Update("deleted_at", at).Error
+}
+
+func (r *taskRepository) RestoreByWorkspaceID(ctx context.Context, workspaceID uint, archivedAt time.Time) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&models.Task{}).
+		Where("project_id IN (?) AND deleted_at = ?", r.db.Unscoped().Model(&models.Project{}).Select("id").Where("workspace_id = ?", workspaceID), archivedAt).
+		Update("deleted_at", nil).Error
+}
+
+func (r *taskRepository) ArchiveDescendants(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Exec(`
+		WITH RECURSIVE descendants AS (
+			SELECT id FROM tasks WHERE parent_id = ? AND deleted_at IS NULL
+			UNION
+			SELECT t.id FROM tasks t JOIN descendants d ON t.parent_id = d.id WHERE t.deleted_at IS NULL
+		)
+		UPDATE tasks SET deleted_at = ? WHERE id IN (SELECT id FROM descendants)`, id, at).Error
+}
+
+func (r *taskRepository) RestoreDescendants(ctx context.Context, id uint, archivedAt time.Time) error {
+	return r.db.WithContext(ctx).Exec(`
+		WITH RECURSIVE descendants AS (
+			SELECT id FROM tasks WHERE parent_id = ? AND deleted_at = ?
+			UNION
+			SELECT t.id FROM tasks t JOIN descendants d ON t.parent_id = d.id WHERE t.deleted_at = ?
+		)
+		UPDATE tasks SET deleted_at = NULL WHERE id IN (SELECT id FROM descendants)`, id, archivedAt, archivedAt).Error
+}
+
+func (r *taskRepository) IsAncestor(ctx context.Context, ancestorID, taskID uint) (bool, error) {
+	// UNION rather than UNION ALL stops the walk should the data ever hold a
+	// cycle.
+	var count int64
+	err := r.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM tasks WHERE id = ?
+			UNION
+			SELECT t.id, t.parent_id FROM tasks t JOIN ancestors a ON t.id = a.parent_id
+		)
+		SELECT COUNT(*) FROM ancestors WHERE id = ?`, taskID, ancestorID).Scan(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *taskRepository) Progress(ctx context.Context, ids []uint) ([]repository.TaskProgressCount, error) {
+	var counts []repository.TaskProgressCount
+	if len(ids) == 0 {
+		return counts, nil
+	}
+	err := r.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE subtasks AS (
+			SELECT parent_id AS root_id, id, completed_at FROM tasks WHERE parent_id IN ? AND deleted_at IS NULL
+			UNION
+			SELECT s.root_id, t.id, t.completed_at FROM tasks t JOIN subtasks s ON t.parent_id = s.id WHERE t.deleted_at IS NULL
+		)
+		SELECT root_id AS task_id, COUNT(*) AS total, COUNT(completed_at) AS completed
+		FROM subtasks GROUP BY root_id`, ids).Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// orderLabels sorts the labels preloaded with tasks by name.
+func orderLabels(db *gorm.DB) *gorm.DB {
+	return db.Order("LOWER(labels.name), labels.id")
+}
+
 func (r *taskRepository) Update(ctx context.Context, task *models.Task) error {
-	return r.db.WithContext(ctx).Save(task).Error
+	// Preloaded associations must not be written back: a stale Assignee would
+	// otherwise overwrite the AssigneeID we are trying to change.
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(task).Error
+}
+
+// taskSortColumns maps each sort option to the SQL expression it orders by.
+// Priority and status are ranked by severity and workflow order rather than
+// alphabetically.
+var taskSortColumns = map[repository.TaskSort]string{
+	repository.TaskSortCreatedAt: "tasks.created_at",
+	repository.TaskSortUpdatedAt: "tasks.updated_at",
+	repository.TaskSortTitle:     "tasks.title",
+	repository.TaskSortPriority:  "CASE tasks.priority WHEN 'LOW' THEN 1 WHEN 'MEDIUM' THEN 2 WHEN 'HIGH' THEN 3 ELSE 0 END",
+	repository.TaskSortStatus:    statusSortColumn(),
+}
+
+// statusSortColumn ranks a task's status by its position in the project's
+// workflow, joined as ws_sort, falling back to the default workflow's
+// positions for projects without a custom one. Unknown statuses rank first.
+func statusSortColumn() string {
+	var cases strings.Builder
+	for _, status := range models.DefaultWorkflow(0).Statuses {
+		fmt.Fprintf(&cases, " WHEN '%s' THEN %d", status.Name, status.Position)
+	}
+	return fmt.Sprintf("COALESCE(ws_sort.position, CASE tasks.status%s ELSE -1 END)", cases.String())
 }
 
-func (r *taskRepository) ListByProjectID(ctx context.Context, projectID uint) ([]models.Task, error) {
+// customSortColumns maps each sortable custom field type to the expression it
+// orders by. Missing values become the lowest value of the type so that keyset
+// pagination never compares against NULL.
+var customSortColumns = map[models.CustomFieldType]string{
+	models.CustomFieldText:   "COALESCE(cf_sort.text_value, '')",
+	models.CustomFieldSelect: "COALESCE(cf_sort.text_value, '')",
+	models.CustomFieldNumber: "COALESCE(cf_sort.number_value, CAST('-Infinity' AS double precision))",
+	models.CustomFieldDate:   "COALESCE(cf_sort.date_value, CAST('0001-01-01 00:00:00+00' AS timestamptz))",
+	models.CustomFieldUser:   "COALESCE(cf_sort.user_value, 0)",
+}
+
+func (r *taskRepository) List(ctx context.Context, filter repository.TaskFilter) ([]models.Task, error) {
+	column, ok := taskSortColumns[filter.Sort]
+	if !ok {
+		column = taskSortColumns[repository.TaskSortCreatedAt]
+	}
+	direction, comparison := "ASC", ">"
+	if filter.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	query := r.filterTasks(r.db.WithContext(ctx).Model(&models.Task{}), filter)
+	if filter.CustomSort != nil {
+		if expr, ok := customSortColumns[filter.CustomSort.Type]; ok {
+			query = query.Joins("LEFT JOIN custom_field_values cf_sort ON cf_sort.task_id = tasks.id AND cf_sort.field_id = ?", filter.CustomSort.ID)
+			column = expr
+		}
+	} else if filter.Sort == repository.TaskSortStatus {
+		query = query.Joins("LEFT JOIN workflow_statuses ws_sort ON ws_sort.project_id = tasks.project_id AND ws_sort.name = tasks.status")
+	}
+	if filter.After != nil {
+		query = query.Where(
+			fmt.Sprintf("(%[1]s %[2]s ?) OR (%[1]s = ? AND tasks.id %[2]s ?)", column, comparison),
+			filter.After.Value, filter.After.Value, filter.After.ID,
+		)
+	}
+
 	var tasks []models.Task
-	if err := r.db.WithContext(ctx).Where("project_id = ?", projectID).Preload("Assignee").Find(&tasks).Error; err != nil {
+	err := query.Preload("Assignee").
+		Preload("Project").
+		Preload("Labels", orderLabels).
+		Order(fmt.Sprintf("%s %s, tasks.id %s", column, direction, direction)).
+		Limit(filter.Limit).
+		Find(&tasks).Error
+	if err != nil {
 		return nil, err
 	}
 	return tasks, nil
 }
 
-type taskHistoryRepository struct {
+func (r *taskRepository) CountByProject(ctx context.Context, filter repository.TaskFilter) ([]repository.ProjectTaskCount, error) {
+	var counts []repository.ProjectTaskCount
+	err := r.filterTasks(r.db.WithContext(ctx).Model(&models.Task{}), filter).
+		Joins("JOIN projects ON projects.id = tasks.project_id").
+		Select("tasks.project_id, projects.name AS project_name, projects.workspace_id, COUNT(*) AS count").
+		Group("tasks.project_id, projects.name, projects.workspace_id").
+		Order("projects.name, tasks.project_id").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// filterTasks applies the filter conditions shared by List and CountByProject.
+func (r *taskRepository) filterTasks(query *gorm.DB, filter repository.TaskFilter) *gorm.DB {
+	if filter.ProjectID != 0 {
+		query = query.Where("tasks.project_id = ?", filter.ProjectID)
+	}
+	if filter.TopLevel {
+		query = query.Where("tasks.parent_id IS NULL")
+	} else if filter.ParentID != 0 {
+		query = query.Where("tasks.parent_id = ?", filter.ParentID)
+	}
+	if filter.MemberID != 0 {
+		query = query.Where("tasks.project_id IN (?)", r.db.Model(&models.Project{}).
+			Select("projects.id").
+			Joins("JOIN workspace_members ON workspace_members.workspace_id = projects.workspace_id").
+			Where("workspace_members.user_id = ?", filter.MemberID))
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("tasks.status IN ?", filter.Statuses)
+	}
+	if len(filter.Priorities) > 0 {
+		query = query.Where("tasks.priority IN ?", filter.Priorities)
+	}
+	if filter.Unassigned {
+		query = query.Where("tasks.assignee_id IS NULL")
+	} else if filter.AssigneeID != 0 {
+		query = query.Where("tasks.assignee_id = ?", filter.AssigneeID)
+	}
+	if filter.Search != "" {
+		pattern := containsPattern(filter.Search)
+		query = query.Where("LOWER(tasks.title) LIKE ? OR LOWER(tasks.description) LIKE ?", pattern, pattern)
+	}
+	if len(filter.LabelIDs) > 0 {
+		labelled := r.db.Table("task_labels").Select("task_id").Where("label_id IN ?", filter.LabelIDs)
+		if filter.AllLabels {
+			labelled = labelled.Group("task_id").Having("COUNT(DISTINCT label_id) = ?", len(filter.LabelIDs))
+		}
+		query = query.Where("tasks.id IN (?)", labelled)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("tasks.created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("tasks.created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.UpdatedAfter != nil {
+		query = query.Where("tasks.updated_at >= ?", *filter.UpdatedAfter)
+	}
+	if filter.UpdatedBefore != nil {
+		query = query.Where("tasks.updated_at < ?", *filter.UpdatedBefore)
+	}
+	if filter.DueAfter != nil {
+		query = query.Where("tasks.due_date >= ?", *filter.DueAfter)
+	}
+	if filter.DueBefore != nil {
+		query = query.Where("tasks.due_date < ?", *filter.DueBefore)
+	}
+	if filter.OverdueAt != nil {
+		query = query.Where("tasks.due_date < ? AND tasks.completed_at IS NULL", *filter.OverdueAt)
+	}
+	for _, custom := range filter.CustomFilters {
+		query = query.Where("tasks.id IN (?)", r.customFieldMatches(custom))
+	}
+	return query
+}
+
+// customFieldMatches selects the IDs of the tasks whose value matches a custom
+// field filter.
+func (r *taskRepository) customFieldMatches(filter repository.CustomFieldFilter) *gorm.DB {
+	values := r.db.Model(&models.CustomFieldValue{}).Select("task_id").Where("field_id = ?", filter.Field.ID)
+	switch filter.Field.Type {
+	case models.CustomFieldNumber:
+		return values.Where("number_value IN ?", filter.Values)
+	case models.CustomFieldUser:
+		return values.Where("user_value IN ?", filter.Values)
+	case models.CustomFieldMultiSelect:
+		return values.Where("EXISTS (SELECT 1 FROM jsonb_array_elements_text(options_value) AS o(v) WHERE o.v IN ?)", filter.Values)
+	case models.CustomFieldDate:
+		// Each value is a UTC day; match any time within it.
+		days := make([]string, 0, len(filter.Values))
+		args := make([]interface{}, 0, 2*len(filter.Values))
+		for _, value := range filter.Values {
+			start, _ := value.(time.Time)
+			days = append(days, "(date_value >= ? AND date_value < ?)")
+			args = append(args, start, start.AddDate(0, 0, 1))
+		}
+		return values.Where(strings.Join(days, " OR "), args...)
+	default:
+		return values.Where("text_value IN ?", filter.Values)
+	}
+}
+
+func (r *taskRepository) ListInvalid(ctx context.Context, priorities []models.TaskPriority, defaultStatuses []models.TaskStatus) ([]models.Task, error) {
+	var tasks []models.Task
+	err := r.db.WithContext(ctx).Unscoped().
+		Where(`priority NOT IN ?
+			OR (EXISTS (SELECT 1 FROM workflow_statuses ws WHERE ws.project_id = tasks.project_id)
+				AND NOT EXISTS (SELECT 1 FROM workflow_statuses ws WHERE ws.project_id = tasks.project_id AND ws.name = tasks.status))
+			OR (NOT EXISTS (SELECT 1 FROM workflow_statuses ws WHERE ws.project_id = tasks.project_id)
+				AND status NOT IN ?)`, priorities, defaultStatuses).
+		Order("id").
+		Find(&tasks).Error
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (r *taskRepository) Repair(ctx context.Context, id uint, status models.TaskStatus, priority models.TaskPriority) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&models.Task{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"status": status, "priority": priority}).Error
+}
+
+func (r *taskRepository) ListNewlyOverdue(ctx context.Context, now time.Time) ([]models.Task, error) {
+	var tasks []models.Task
+	err := r.db.WithContext(ctx).
+		Where("due_date <= ? AND completed_at IS NULL AND overdue_at IS NULL", now).
+		Order("due_date, id").
+		Find(&tasks).Error
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (r *taskRepository) MarkOverdue(ctx context.Context, id uint, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Task{}).
+		Where("id = ? AND overdue_at IS NULL", id).
+		UpdateColumn("overdue_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *taskRepository) SyncCompletion(ctx context.Context, projectID uint, doneStatuses []models.TaskStatus, at time.Time) error {
+	db := r.db.WithContext(ctx).Unscoped().Model(&models.Task{})
+	if len(doneStatuses) == 0 {
+		return db.Where("project_id = ? AND completed_at IS NOT NULL", projectID).
+			UpdateColumn("completed_at", nil).Error
+	}
+	err := db.Session(&gorm.Session{}).
+		Where("project_id = ? AND status IN ? AND completed_at IS NULL", projectID, doneStatuses).
+		UpdateColumn("completed_at", at).Error
+	if err != nil {
+		return err
+	}
+	return db.Session(&gorm.Session{}).
+		Where("project_id = ? AND status NOT IN ? AND completed_at IS NOT NULL", projectID, doneStatuses).
+		UpdateColumn("completed_at", nil).Error
+}
+
+func (r *taskRepository) ListStatusesInUse(ctx context.Context, projectID uint) ([]models.TaskStatus, error) {
+	var statuses []models.TaskStatus
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.Task{}).
+		Where("project_id = ?", projectID).
+		Distinct().
+		Pluck("status", &statuses).Error
+	if err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+func (r *taskRepository) ListByAssigneeID(ctx context.Context, assigneeID uint) ([]models.Task, error) {
+	var tasks []models.Task
+	if err := r.db.WithContext(ctx).Where("assignee_id = ?", assigneeID).Preload("Project").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+type taskCommentRepository struct {
 	db *gorm.DB
 }
 
-func NewTaskHistoryRepository(db *gorm.DB) repository.TaskHistoryRepository {
-	return &taskHistoryRepository{db: db}
+func NewTaskCommentRepository(db *gorm.DB) repository.TaskCommentRepository {
+	return &taskCommentRepository{db: db}
 }
 
-func (r *taskHistoryRepository) Create(ctx context.Context, history *models.TaskHistory) error {
-	return r.db.WithContext(ctx).Create(history).Error
+func (r *taskCommentRepository) Create(ctx context.Context, comment *models.TaskComment) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(comment).Error
 }
 
-func (r *taskHistoryRepository) ListByTaskID(ctx context.Context, taskID uint) ([]models.TaskHistory, error) {
-	var history []models.TaskHistory
-	if err := r.db.WithContext(ctx).Where("task_id = ?", taskID).Order("created_at desc").Find(&history).Error; err != nil {
+func (r *taskCommentRepository) GetByID(ctx context.Context, id uint) (*models.TaskComment, error) {
+	var comment models.TaskComment
+	if err := r.db.WithContext(ctx).Preload("Author").Preload("Mentions.User").First(&comment, id).Error; err != nil {
 		return nil, err
 	}
-	return history, nil
+	return &comment, nil
 }
 
-type Repository struct {
-	db          *gorm.DB
-	users       repository.UserRepository
-	workspaces  repository.WorkspaceRepository
-	projects    repository.ProjectRepository
-	tasks       repository.TaskRepository
-	taskHistory repository.TaskHistoryRepository
+func (r *taskCommentRepository) Update(ctx context.Context, comment *models.TaskComment) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(comment).Error
 }
 
-func NewRepository(db *gorm.DB) *Repository {
-	return &Repository{
-		db:          db,
-		users:       NewUserRepository(db),
-		workspaces:  NewWorkspaceRepository(db),
-		projects:    NewProjectRepository(db),
-		tasks:       NewTaskRepository(db),
-		taskHistory: NewTaskHistoryRepository(db),
+func (r *taskCommentRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.TaskComment{}, id).Error
+}
+
+func (r *taskCommentRepository) ListByTaskID(ctx context.Context, taskID uint, limit, offset int) ([]models.TaskComment, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.TaskComment{}).Where("task_id = ?", taskID).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var comments []models.TaskComment
+	err := query.Preload("Author").
+		Preload("Mentions.User").
+		Order("created_at, id").
+		Limit(limit).
+		Offset(offset).
+		Find(&comments).Error
+	if err != nil {
+		return nil, 0, err
 	}
+	return comments, total, nil
 }
 
-func (r *Repository) Users() repository.UserRepository {
-	return r.users
+func (r *taskCommentRepository) ReplaceMentions(ctx context.Context, commentID uint, userIDs []uint) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Where("comment_id = ?", commentID).Delete(&models.CommentMention{}).Error; err != nil {
+		return err
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+	mentions := make([]models.CommentMention, 0, len(userIDs))
+	for _, userID := range userIDs {
+		mentions = append(mentions, models.CommentMention{CommentID: commentID, UserID: userID})
+	}
+	return db.Omit(clause.Associations).Create(&mentions).Error
 }
 
-func (r *Repository) Workspaces() repository.WorkspaceRepository {
-	return r.workspaces
+func (r *taskCommentRepository) CreateEdit(ctx context.Context, edit *models.TaskCommentEdit) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(edit).Error
 }
 
-func (r *Repository) Projects() repository.ProjectRepository {
-	return r.projects
+func (r *taskCommentRepository) ListEdits(ctx context.Context, commentID uint) ([]models.TaskCommentEdit, error) {
+	var edits []models.TaskCommentEdit
+	err := r.db.WithContext(ctx).
+		Where("comment_id = ?", commentID).
+		Preload("Editor").
+		Order("created_at desc, id desc").
+		Find(&edits).Error
+	if err != nil {
+		return nil, err
+	}
+	return edits, nil
 }
 
-func (r *Repository) Tasks() repository.TaskRepository {
-	return r.tasks
+type workflowRepository struct {
+	db *gorm.DB
 }
 
-func (r *Repository) TaskHistory() repository.TaskHistoryRepository {
-	return r.taskHistory
+func NewWorkflowRepository(db *gorm.DB) repository.WorkflowRepository {
+	return &workflowRepository{db: db}
+}
+
+func (r *workflowRepository) Get(ctx context.Context, projectID uint) (*models.Workflow, error) {
+	var statuses []models.WorkflowStatus
+	if err := r.db.WithContext(ctx).Where("project_id = ?", projectID).Order("position, id").Find(&statuses).Error; err != nil {
+		return nil, err
+	}
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+
+	var transitions []models.WorkflowTransition
+	if err := r.db.WithContext(ctx).Where("project_id = ?", projectID).Order("id").Find(&transitions).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.Workflow{
+		ProjectID:   projectID,
+		Custom:      true,
+		Statuses:    statuses,
+		Transitions: transitions,
+	}, nil
+}
+
+func (r *workflowRepository) Replace(ctx context.Context, projectID uint, statuses []models.WorkflowStatus, transitions []models.WorkflowTransition) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Where("project_id = ?", projectID).Delete(&models.WorkflowTransition{}).Error; err != nil {
+		return err
+	}
+	if err := db.Where("project_id = ?", projectID).Delete(&models.WorkflowStatus{}).Error; err != nil {
+		return err
+	}
+	if len(statuses) > 0 {
+		if err := db.Create(&statuses).Error; err != nil {
+			return err
+		}
+	}
+	if len(transitions) > 0 {
+		if err := db.Create(&transitions).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type customFieldRepository struct {
+	db *gorm.DB
+}
+
+func NewCustomFieldRepository(db *gorm.DB) repository.CustomFieldRepository {
+	return &customFieldRepository{db: db}
+}
+
+func (r *customFieldRepository) Create(ctx context.Context, field *models.CustomField) error {
+	return r.db.WithContext(ctx).Create(field).Error
+}
+
+func (r *customFieldRepository) GetByID(ctx context.Context, id uint) (*models.CustomField, error) {
+	var field models.CustomField
+	if err := r.db.WithContext(ctx).First(&field, id).Error; err != nil {
+		return nil, err
+	}
+	return &field, nil
+}
+
+func (r *customFieldRepository) Update(ctx context.Context, field *models.CustomField) error {
+	return r.db.WithContext(ctx).Save(field).Error
+}
+
+func (r *customFieldRepository) Delete(ctx context.Context, id uint) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Where("field_id = ?", id).Delete(&models.CustomFieldValue{}).Error; err != nil {
+		return err
+	}
+	return db.Delete(&models.CustomField{}, id).Error
+}
+
+func (r *customFieldRepository) ListByProjectIDs(ctx context.Context, projectIDs []uint) ([]models.CustomField, error) {
+	var fields []models.CustomField
+	if len(projectIDs) == 0 {
+		return fields, nil
+	}
+	err := r.db.WithContext(ctx).
+		Where("project_id IN ?", projectIDs).
+		Order("project_id, position, id").
+		Find(&fields).Error
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func (r *customFieldRepository) ListValues(ctx context.Context, taskIDs []uint) ([]models.CustomFieldValue, error) {
+	var values []models.CustomFieldValue
+	if len(taskIDs) == 0 {
+		return values, nil
+	}
+	if err := r.db.WithContext(ctx).Where("task_id IN ?", taskIDs).Find(&values).Error; err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (r *customFieldRepository) SetValue(ctx context.Context, value *models.CustomFieldValue) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "task_id"}, {Name: "field_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"text_value", "number_value", "date_value", "options_value", "user_value", "updated_at"}),
+	}).Create(value).Error
+}
+
+func (r *customFieldRepository) DeleteValue(ctx context.Context, taskID, fieldID uint) error {
+	return r.db.WithContext(ctx).
+		Where("task_id = ? AND field_id = ?", taskID, fieldID).
+		Delete(&models.CustomFieldValue{}).Error
+}
+
+func (r *customFieldRepository) CountMissingValues(ctx context.Context, projectID, fieldID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Task{}).
+		Where("project_id = ?", projectID).
+		Where("NOT EXISTS (SELECT 1 FROM custom_field_values v WHERE v.task_id = tasks.id AND v.field_id = ?)", fieldID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *customFieldRepository) CountOptionUses(ctx context.Context, fieldID uint, options []string) (int64, error) {
+	var count int64
+	if len(options) == 0 {
+		return 0, nil
+	}
+	err := r.db.WithContext(ctx).Model(&models.CustomFieldValue{}).
+		Where("field_id = ?", fieldID).
+		Where("text_value IN ? OR EXISTS (SELECT 1 FROM jsonb_array_elements_text(options_value) AS o(v) WHERE o.v IN ?)", options, options).
+		Count(&count).Error
+	return count, err
+}
+
+type attachmentRepository struct {
+	db *gorm.DB
+}
+
+func NewAttachmentRepository(db *gorm.DB) repository.AttachmentRepository {
+	return &attachmentRepository{db: db}
+}
+
+func (r *attachmentRepository) Create(ctx context.Context, attachment *models.Attachment) error {
+	return r.db.WithContext(ctx).Create(attachment).Error
+}
+
+func (r *attachmentRepository) GetByID(ctx context.Context, id uint) (*models.Attachment, error) {
+	var attachment models.Attachment
+	if err := r.db.WithContext(ctx).Preload("Uploader").First(&attachment, id).Error; err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (r *attachmentRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Attachment{}, id).Error
+}
+
+func (r *attachmentRepository) ListByTaskID(ctx context.Context, taskID uint) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := r.db.WithContext(ctx).Preload("Uploader").
+		Where("task_id = ?", taskID).
+		Order("created_at, id").
+		Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *attachmentRepository) ListArchived(ctx context.Context, before time.Time, limit int) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	err := r.db.WithContext(ctx).
+		Joins("JOIN tasks ON tasks.id = attachments.task_id").
+		Joins("JOIN projects ON projects.id = tasks.project_id").
+		Joins("JOIN workspaces ON workspaces.id = projects.workspace_id").
+		Where("tasks.deleted_at < ? OR projects.deleted_at < ? OR workspaces.deleted_at < ?", before, before, before).
+		Order("attachments.id").
+		Limit(limit).
+		Find(&attachments).Error
+	return attachments, err
+}
+
+type labelRepository struct {
+	db *gorm.DB
+}
+
+func NewLabelRepository(db *gorm.DB) repository.LabelRepository {
+	return &labelRepository{db: db}
+}
+
+func (r *labelRepository) Create(ctx context.Context, label *models.Label) error {
+	return r.db.WithContext(ctx).Create(label).Error
+}
+
+func (r *labelRepository) GetByID(ctx context.Context, id uint) (*models.Label, error) {
+	var label models.Label
+	if err := r.db.WithContext(ctx).First(&label, id).Error; err != nil {
+		return nil, err
+	}
+	return &label, nil
+}
+
+func (r *labelRepository) GetByName(ctx context.Context, workspaceID uint, name string) (*models.Label, error) {
+	var label models.Label
+	err := r.db.WithContext(ctx).
+		Where("workspace_id = ? AND LOWER(name) = LOWER(?)", workspaceID, name).
+		First(&label).Error
+	if err != nil {
+		return nil, err
+	}
+	return &label, nil
+}
+
+func (r *labelRepository) Update(ctx context.Context, label *models.Label) error {
+	return r.db.WithContext(ctx).Save(label).Error
+}
+
+func (r *labelRepository) Delete(ctx context.Context, id uint) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Exec("DELETE FROM task_labels WHERE label_id = ?", id).Error; err != nil {
+		return err
+	}
+	return db.Delete(&models.Label{}, id).Error
+}
+
+func (r *labelRepository) ListByWorkspaceID(ctx context.Context, workspaceID uint) ([]models.Label, error) {
+	var labels []models.Label
+	if err := r.db.WithContext(ctx).Where("workspace_id = ?", workspaceID).Order("LOWER(name), id").Find(&labels).Error; err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+func (r *labelRepository) AddToTask(ctx context.Context, taskID, labelID uint) (bool, error) {
+	result := r.db.WithContext(ctx).Exec(
+		"INSERT INTO task_labels (task_id, label_id) VALUES (?, ?) ON CONFLICT DO NOTHING", taskID, labelID)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *labelRepository) RemoveFromTask(ctx context.Context, taskID, labelID uint) (bool, error) {
+	result := r.db.WithContext(ctx).Exec("DELETE FROM task_labels WHERE task_id = ? AND label_id = ?", taskID, labelID)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+type taskDependencyRepository struct {
+	db *gorm.DB
+}
+
+func NewTaskDependencyRepository(db *gorm.DB) repository.TaskDependencyRepository {
+	return &taskDependencyRepository{db: db}
+}
+
+func (r *taskDependencyRepository) Create(ctx context.Context, dependency *models.TaskDependency) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(dependency).Error
+}
+
+func (r *taskDependencyRepository) Get(ctx context.Context, blockingID, blockedID uint) (*models.TaskDependency, error) {
+	var dependency models.TaskDependency
+	err := r.db.WithContext(ctx).
+		Where("blocking_task_id = ? AND blocked_task_id = ?", blockingID, blockedID).
+		First(&dependency).Error
+	if err != nil {
+		return nil, err
+	}
+	return &dependency, nil
+}
+
+func (r *taskDependencyRepository) Delete(ctx context.Context, taskID, otherID uint) (*models.TaskDependency, error) {
+	var dependency models.TaskDependency
+	err := r.db.WithContext(ctx).
+		Where("(blocking_task_id = ? AND blocked_task_id = ?) OR (blocking_task_id = ? AND blocked_task_id = ?)", taskID, otherID, otherID, taskID).
+		First(&dependency).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).Delete(&dependency).Error; err != nil {
+		return nil, err
+	}
+	return &dependency, nil
+}
+
+func (r *taskDependencyRepository) ListByTaskID(ctx context.Context, taskID uint) ([]models.TaskDependency, error) {
+	live := r.db.Model(&models.Task{}).Select("id")
+	var dependencies []models.TaskDependency
+	err := r.db.WithContext(ctx).
+		Where("blocking_task_id = ? OR blocked_task_id = ?", taskID, taskID).
+		Where("blocking_task_id IN (?) AND blocked_task_id IN (?)", live, live).
+		Preload("BlockingTask").
+		Preload("BlockedTask").
+		Order("created_at, id").
+		Find(&dependencies).Error
+	if err != nil {
+		return nil, err
+	}
+	return dependencies, nil
+}
+
+func (r *taskDependencyRepository) Blocks(ctx context.Context, blockingID, blockedID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE downstream AS (
+			SELECT blocked_task_id AS id FROM task_dependencies WHERE blocking_task_id = ?
+			UNION
+			SELECT d.blocked_task_id FROM task_dependencies d JOIN downstream ON d.blocking_task_id = downstream.id
+		)
+		SELECT COUNT(*) FROM downstream WHERE id = ?`, blockingID, blockedID).Scan(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *taskDependencyRepository) ListBlocked(ctx context.Context, ids []uint) ([]uint, error) {
+	var blocked []uint
+	if len(ids) == 0 {
+		return blocked, nil
+	}
+	err := r.db.WithContext(ctx).Model(&models.TaskDependency{}).
+		Joins("JOIN tasks ON tasks.id = task_dependencies.blocking_task_id").
+		Where("task_dependencies.blocked_task_id IN ?", ids).
+		Where("tasks.deleted_at IS NULL AND tasks.completed_at IS NULL").
+		Distinct().
+		Pluck("task_dependencies.blocked_task_id", &blocked).Error
+	if err != nil {
+		return nil, err
+	}
+	return blocked, nil
+}
+
+func (r *taskDependencyRepository) LockWorkspace(ctx context.Context, workspaceID uint) error {
+	return r.db.WithContext(ctx).Exec("SELECT pg_advisory_xact_lock(hashtext('task_dependencies'), CAST(? AS integer))", workspaceID).Error
+}
+
+type taskHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewTaskHistoryRepository(db *gorm.DB) repository.TaskHistoryRepository {
+	return &taskHistoryRepository{db: db}
+}
+
+func (r *taskHistoryRepository) Create(ctx context.Context, history *models.TaskHistory) error {
+	return r.db.WithContext(ctx).Create(history).Error
+}
+
+func (r *taskHistoryRepository) ListByTaskID(ctx context.Context, taskID uint) ([]models.TaskHistory, error) {
+	var history []models.TaskHistory
+	if err := r.db.WithContext(ctx).Where("task_id = ?", taskID).Preload("User").Order("created_at desc").Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func (r *taskHistoryRepository) ListActivity(ctx context.Context, filter repository.ActivityFilter) ([]models.TaskHistory, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.TaskHistory{}).
+		Joins("JOIN tasks ON tasks.id = task_histories.task_id")
+
+	if filter.ProjectID != 0 {
+		query = query.Where("tasks.project_id = ?", filter.ProjectID)
+	}
+	if filter.WorkspaceID != 0 {
+		query = query.Joins("JOIN projects ON projects.id = tasks.project_id").
+			Where("projects.workspace_id = ?", filter.WorkspaceID)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("task_histories.user_id = ?", filter.UserID)
+	}
+	if filter.ChangeType != "" {
+		query = query.Where("task_histories.change_type = ?", filter.ChangeType)
+	}
+	if filter.Since != nil {
+		query = query.Where("task_histories.created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("task_histories.created_at < ?", *filter.Until)
+	}
+
+	// Start a new session so the count and page queries don't share state.
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var history []models.TaskHistory
+	err := query.Select("task_histories.*").
+		Preload("User").
+		Preload("Task", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Order("task_histories.created_at desc, task_histories.id desc").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&history).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return history, total, nil
+}
+
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) repository.AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+func (r *auditLogRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *auditLogRepository) List(ctx context.Context, limit, offset int) ([]models.AuditLog, int64, error) {
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.AuditLog{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.AuditLog
+	err := r.db.WithContext(ctx).
+		Preload("Actor").
+		Order("created_at desc, id desc").
+		Limit(limit).
+		Offset(offset).
+		Find(&entries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) repository.RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *refreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *refreshTokenRepository) MarkReplaced(ctx context.Context, id, replacedByID uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "replaced_by_id": replacedByID})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *refreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *refreshTokenRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.RefreshToken{}).Error
+}
+
+type revokedTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRevokedTokenRepository(db *gorm.DB) repository.RevokedTokenRepository {
+	return &revokedTokenRepository{db: db}
+}
+
+func (r *revokedTokenRepository) Create(ctx context.Context, token *models.RevokedToken) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(token).Error
+}
+
+func (r *revokedTokenRepository) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.RevokedToken{}).Where("token_id = ?", tokenID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *revokedTokenRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&models.RevokedToken{}).Error
+}
+
+// searchSources are the queries a search unions, one per result type. Each
+// selects the hit columns plus the document its snippet is taken from.
+var searchSources = map[repository.SearchResultType]string{
+	repository.SearchResultTask: `
+		SELECT 'task' AS type, tasks.id, tasks.id AS task_id, tasks.project_id, projects.workspace_id,
+			tasks.title, tasks.title || E'\n' || COALESCE(tasks.description, '') AS document,
+			ts_rank(tasks.search_vector, q.query) AS rank
+		FROM tasks
+		JOIN projects ON projects.id = tasks.project_id AND projects.deleted_at IS NULL
+		JOIN workspaces ON workspaces.id = projects.workspace_id AND workspaces.deleted_at IS NULL
+		CROSS JOIN q
+		WHERE tasks.deleted_at IS NULL AND tasks.search_vector @@ q.query`,
+	repository.SearchResultComment: `
+		SELECT 'comment' AS type, task_comments.id, task_comments.task_id, tasks.project_id, projects.workspace_id,
+			tasks.title, task_comments.body AS document,
+			ts_rank(task_comments.search_vector, q.query) AS rank
+		FROM task_comments
+		JOIN tasks ON tasks.id = task_comments.task_id AND tasks.deleted_at IS NULL
+		JOIN projects ON projects.id = tasks.project_id AND projects.deleted_at IS NULL
+		JOIN workspaces ON workspaces.id = projects.workspace_id AND workspaces.deleted_at IS NULL
+		CROSS JOIN q
+		WHERE task_comments.deleted_at IS NULL AND task_comments.search_vector @@ q.query`,
+	repository.SearchResultProject: `
+		SELECT 'project' AS type, projects.id, CAST(NULL AS bigint) AS task_id, projects.id AS project_id, projects.workspace_id,
+			projects.name AS title, projects.name AS document,
+			ts_rank(projects.search_vector, q.query) AS rank
+		FROM projects
+		JOIN workspaces ON workspaces.id = projects.workspace_id AND workspaces.deleted_at IS NULL
+		CROSS JOIN q
+		WHERE projects.deleted_at IS NULL AND projects.search_vector @@ q.query`,
+}
+
+// searchScope limits every search source to the filter's workspaces.
+const searchScope = `
+		AND (CAST(@member AS bigint) = 0 OR projects.workspace_id IN (
+			SELECT workspace_id FROM workspace_members WHERE user_id = @member))
+		AND (CAST(@workspace AS bigint) = 0 OR projects.workspace_id = @workspace)`
+
+type searchRepository struct {
+	db *gorm.DB
+}
+
+func NewSearchRepository(db *gorm.DB) repository.SearchRepository {
+	return &searchRepository{db: db}
+}
+
+func (r *searchRepository) Search(ctx context.Context, filter repository.SearchFilter) ([]repository.SearchHit, int64, error) {
+	types := filter.Types
+	if len(types) == 0 {
+		types = []repository.SearchResultType{
+			repository.SearchResultTask,
+			repository.SearchResultComment,
+			repository.SearchResultProject,
+		}
+	}
+	sources := make([]string, 0, len(types))
+	for _, t := range types {
+		if source, ok := searchSources[t]; ok {
+			sources = append(sources, source+searchScope)
+		}
+	}
+	if len(sources) == 0 {
+		return []repository.SearchHit{}, 0, nil
+	}
+
+	with := `WITH q AS (SELECT websearch_to_tsquery('english', @query) AS query),
+		hits AS (` + strings.Join(sources, "\n\t\tUNION ALL") + `)`
+	args := map[string]interface{}{
+		"query":     filter.Query,
+		"member":    filter.MemberID,
+		"workspace": filter.WorkspaceID,
+		"limit":     filter.Limit,
+		"offset":    filter.Offset,
+	}
+	db := r.db.WithContext(ctx)
+
+	var total int64
+	if err := db.Raw(with+` SELECT COUNT(*) FROM hits`, args).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	hits := []repository.SearchHit{}
+	if total == 0 {
+		return hits, 0, nil
+	}
+
+	// Snippets are only built for the page being returned. The document is
+	// escaped first so the <mark> tags are the only markup in a snippet.
+	err := db.Raw(with+`
+		SELECT page.type, page.id, page.task_id, page.project_id, page.workspace_id, page.title, page.rank,
+			ts_headline('english',
+				replace(replace(replace(page.document, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+				q.query, 'StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10, MaxFragments=2') AS snippet
+		FROM (SELECT * FROM hits ORDER BY rank DESC, type, id LIMIT @limit OFFSET @offset) page
+		CROSS JOIN q
+		ORDER BY page.rank DESC, page.type, page.id`, args).Scan(&hits).Error
+	return hits, total, err
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) repository.WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	return r.db.WithContext(ctx).Create(webhook).Error
+}
+
+func (r *webhookRepository) GetByID(ctx context.Context, id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := r.db.WithContext(ctx).First(&webhook, id).Error; err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *webhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
+	return r.db.WithContext(ctx).Save(webhook).Error
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, id uint) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+		return err
+	}
+	return db.Delete(&models.Webhook{}, id).Error
+}
+
+func (r *webhookRepository) ListByWorkspaceID(ctx context.Context, workspaceID uint) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	if err := r.db.WithContext(ctx).Where("workspace_id = ?", workspaceID).Order("id").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *webhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Create(delivery).Error
+}
+
+func (r *webhookRepository) GetDelivery(ctx context.Context, id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := r.db.WithContext(ctx).First(&delivery, id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Save(delivery).Error
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, webhookID uint, limit, offset int) ([]models.WebhookDelivery, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Offset(offset).Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE webhook_deliveries SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at, id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED)
+		RETURNING *`,
+		now.Add(lease), models.WebhookDeliveryPending, now, limit,
+	).Scan(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID < deliveries[j].ID })
+	return deliveries, nil
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) repository.NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) GetPreference(ctx context.Context, userID uint) (*models.NotificationPreference, error) {
+	var preference models.NotificationPreference
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&preference).Error; err != nil {
+		return nil, err
+	}
+	return &preference, nil
+}
+
+func (r *notificationRepository) SavePreference(ctx context.Context, preference *models.NotificationPreference) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}}, UpdateAll: true}).
+		Create(preference).Error
+}
+
+func (r *notificationRepository) CreateDigestItem(ctx context.Context, item *models.EmailDigestItem) error {
+	return r.db.WithContext(ctx).Create(item).Error
+}
+
+func (r *notificationRepository) ListDigestRecipients(ctx context.Context) ([]uint, error) {
+	var userIDs []uint
+	err := r.db.WithContext(ctx).Model(&models.EmailDigestItem{}).
+		Distinct("user_id").
+		Order("user_id").
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+func (r *notificationRepository) ListDigestItems(ctx context.Context, userID uint) ([]models.EmailDigestItem, error) {
+	var items []models.EmailDigestItem
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at, id").Find(&items).Error
+	return items, err
+}
+
+func (r *notificationRepository) DeleteDigestItems(ctx context.Context, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Delete(&models.EmailDigestItem{}, ids).Error
+}
+
+func (r *notificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	return r.db.WithContext(ctx).Create(notification).Error
+}
+
+func (r *notificationRepository) GetByID(ctx context.Context, id uint) (*models.Notification, error) {
+	var notification models.Notification
+	if err := r.db.WithContext(ctx).Preload("Actor").First(&notification, id).Error; err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+func (r *notificationRepository) ListByUserID(ctx context.Context, userID uint, unreadOnly bool, limit, offset int) ([]models.Notification, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var notifications []models.Notification
+	err := query.Preload("Actor").
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&notifications).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
+
+func (r *notificationRepository) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *notificationRepository) MarkRead(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("id = ? AND read_at IS NULL", id).
+		Update("read_at", at).Error
+}
+
+func (r *notificationRepository) MarkAllRead(ctx context.Context, userID uint, at time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", at)
+	return result.RowsAffected, result.Error
+}
+
+type Repository struct {
+	db            *gorm.DB
+	users         repository.UserRepository
+	workspaces    repository.WorkspaceRepository
+	members       repository.WorkspaceMemberRepository
+	projects      repository.ProjectRepository
+	tasks         repository.TaskRepository
+	workflows     repository.WorkflowRepository
+	dependencies  repository.TaskDependencyRepository
+	labels        repository.LabelRepository
+	customFields  repository.CustomFieldRepository
+	attachments   repository.AttachmentRepository
+	search        repository.SearchRepository
+	webhooks      repository.WebhookRepository
+	notifications repository.NotificationRepository
+	comments      repository.TaskCommentRepository
+	taskHistory   repository.TaskHistoryRepository
+	auditLogs     repository.AuditLogRepository
+	refresh       repository.RefreshTokenRepository
+	revoked       repository.RevokedTokenRepository
+}
+
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{
+		db:            db,
+		users:         NewUserRepository(db),
+		workspaces:    NewWorkspaceRepository(db),
+		members:       NewWorkspaceMemberRepository(db),
+		projects:      NewProjectRepository(db),
+		tasks:         NewTaskRepository(db),
+		workflows:     NewWorkflowRepository(db),
+		dependencies:  NewTaskDependencyRepository(db),
+		labels:        NewLabelRepository(db),
+		customFields:  NewCustomFieldRepository(db),
+		attachments:   NewAttachmentRepository(db),
+		search:        NewSearchRepository(db),
+		webhooks:      NewWebhookRepository(db),
+		notifications: NewNotificationRepository(db),
+		comments:      NewTaskCommentRepository(db),
+		taskHistory:   NewTaskHistoryRepository(db),
+		auditLogs:     NewAuditLogRepository(db),
+		refresh:       NewRefreshTokenRepository(db),
+		revoked:       NewRevokedTokenRepository(db),
+	}
+}
+
+func (r *Repository) Users() repository.UserRepository {
+	return r.users
+}
+
+func (r *Repository) Workspaces() repository.WorkspaceRepository {
+	return r.workspaces
+}
+
+func (r *Repository) WorkspaceMembers() repository.WorkspaceMemberRepository {
+	return r.members
+}
+
+func (r *Repository) Projects() repository.ProjectRepository {
+	return r.projects
+}
+
+func (r *Repository) Tasks() repository.TaskRepository {
+	return r.tasks
+}
+
+func (r *Repository) Workflows() repository.WorkflowRepository {
+	return r.workflows
+}
+
+func (r *Repository) TaskDependencies() repository.TaskDependencyRepository {
+	return r.dependencies
+}
+
+func (r *Repository) Labels() repository.LabelRepository {
+	return r.labels
+}
+
+func (r *Repository) CustomFields() repository.CustomFieldRepository {
+	return r.customFields
+}
+
+func (r *Repository) Attachments() repository.AttachmentRepository {
+	return r.attachments
+}
+
+func (r *Repository) Search() repository.SearchRepository {
+	return r.search
+}
+
+func (r *Repository) Webhooks() repository.WebhookRepository {
+	return r.webhooks
+}
+
+func (r *Repository) Notifications() repository.NotificationRepository {
+	return r.notifications
+}
+
+func (r *Repository) TaskComments() repository.TaskCommentRepository {
+	return r.comments
+}
+
+func (r *Repository) TaskHistory() repository.TaskHistoryRepository {
+	return r.taskHistory
+}
+
+func (r *Repository) AuditLogs() repository.AuditLogRepository {
+	return r.auditLogs
+}
+
+func (r *Repository) RefreshTokens() repository.RefreshTokenRepository {
+	return r.refresh
+}
+
+func (r *Repository) RevokedTokens() repository.RevokedTokenRepository {
+	return r.revoked
+}
+
+func (r *Repository) Transaction(ctx context.Context, fn func(tx repository.Repository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(NewRepository(tx))
+	})
 }
@@ -2,44 +2,462 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
 )
 
+// UserFilter narrows down a user listing. Zero-valued fields are ignored.
+type UserFilter struct {
+	// Search matches a case-insensitive substring of the username or email.
+	Search string
+	Role   models.UserRole
+	Limit  int
+	Offset int
+}
+
 type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	GetByID(ctx context.Context, id uint) (*models.User, error)
 	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	Update(ctx context.Context, user *models.User) error
+	// CountEnabledByRole counts the users with role whose accounts are not
+	// disabled. Inside a transaction the counted rows stay locked until it
+	// ends, so concurrent changes to them happen one after the other.
+	CountEnabledByRole(ctx context.Context, role models.UserRole) (int64, error)
+	// List returns the page of users matching filter, ordered by ID, along with
+	// the total number of matching users.
+	List(ctx context.Context, filter UserFilter) ([]models.User, int64, error)
 }
 
+// Archiving is a soft delete: GetByID and the List methods skip archived rows,
+// GetIncludingArchived does not. Archive and Restore methods that act on the
+// children of a parent take the parent's archive timestamp, so a restore only
+// brings back the rows that were archived together with the parent.
+
 type WorkspaceRepository interface {
 	Create(ctx context.Context, workspace *models.Workspace) error
 	GetByID(ctx context.Context, id uint) (*models.Workspace, error)
+	GetIncludingArchived(ctx context.Context, id uint) (*models.Workspace, error)
 	ListByUserID(ctx context.Context, userID uint) ([]models.Workspace, error)
+	// ListOwnedByUserID returns the workspaces owned by a user, archived ones
+	// included.
+	ListOwnedByUserID(ctx context.Context, userID uint) ([]models.Workspace, error)
+	Update(ctx context.Context, workspace *models.Workspace) error
+	Archive(ctx context.Context, id uint, at time.Time) error
+	Restore(ctx context.Context, id uint) error
+}
+
+type WorkspaceMemberRepository interface {
+	Create(ctx context.Context, member *models.WorkspaceMember) error
+	Get(ctx context.Context, workspaceID, userID uint) (*models.WorkspaceMember, error)
+	ListByWorkspaceID(ctx context.Context, workspaceID uint) ([]models.WorkspaceMember, error)
+	Update(ctx context.Context, member *models.WorkspaceMember) error
+	Delete(ctx context.Context, workspaceID, userID uint) error
+	DeleteByUserID(ctx context.Context, userID uint) error
 }
 
 type ProjectRepository interface {
 	Create(ctx context.Context, project *models.Project) error
 	GetByID(ctx context.Context, id uint) (*models.Project, error)
+	GetIncludingArchived(ctx context.Context, id uint) (*models.Project, error)
 	ListByWorkspaceID(ctx context.Context, workspaceID uint) ([]models.Project, error)
+	Update(ctx context.Context, project *models.Project) error
+	Archive(ctx context.Context, id uint, at time.Time) error
+	Restore(ctx context.Context, id uint) error
+	ArchiveByWorkspaceID(ctx context.Context, workspaceID uint, at time.Time) error
+	RestoreByWorkspaceID(ctx context.Context, workspaceID uint, archivedAt time.Time) error
+}
+
+// ProjectTaskCount is the number of tasks matching a filter in one project.
+type ProjectTaskCount struct {
+	ProjectID   uint
+	ProjectName string
+	WorkspaceID uint
+	Count       int64
+}
+
+// TaskProgressCount is the number of live subtasks below a task, at every
+// depth, and how many of them are completed.
+type TaskProgressCount struct {
+	TaskID    uint
+	Total     int64
+	Completed int64
+}
+
+// TaskSort names a column task listings can be ordered by.
+type TaskSort string
+
+const (
+	TaskSortCreatedAt TaskSort = "created_at"
+	TaskSortUpdatedAt TaskSort = "updated_at"
+	TaskSortTitle     TaskSort = "title"
+	TaskSortPriority  TaskSort = "priority"
+	TaskSortStatus    TaskSort = "status"
+)
+
+// TaskCursor marks the last task of the previous page. Value is that task's
+// value for the sort column: a time.Time for the timestamps, a string for the
+// title and an int rank for priority and status.
+type TaskCursor struct {
+	Value interface{}
+	ID    uint
+}
+
+// CustomFieldFilter keeps tasks whose value for Field matches one of Values.
+// Values hold strings for text and select fields, float64 for numbers, the
+// start of a UTC day for dates and uint user IDs for user fields. Multi-select
+// values match when any of their options is listed.
+type CustomFieldFilter struct {
+	Field  *models.CustomField
+	Values []interface{}
+}
+
+// TaskFilter narrows down a task listing. Zero-valued fields are ignored.
+// Results are ordered by Sort and then by ID, both in the same direction.
+type TaskFilter struct {
+	ProjectID uint
+	// ParentID keeps only the direct subtasks of this task; TopLevel keeps
+	// only tasks without a parent.
+	ParentID   uint
+	TopLevel   bool
+	Statuses   []models.TaskStatus
+	Priorities []models.TaskPriority
+	AssigneeID uint
+	Unassigned bool
+	// MemberID restricts the listing to live projects in workspaces this user
+	// is a member of.
+	MemberID uint
+	// Search matches a case-insensitive substring of the title or description.
+	Search string
+	// LabelIDs keeps tasks carrying any of the labels, or all of them when
+	// AllLabels is set.
+	LabelIDs      []uint
+	AllLabels     bool
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	DueAfter      *time.Time
+	DueBefore     *time.Time
+	// OverdueAt keeps only tasks that are not completed and were due before it.
+	OverdueAt     *time.Time
+	CustomFilters []CustomFieldFilter
+	// CustomSort orders by the task's value for a custom field instead of
+	// Sort. Tasks without a value sort as the lowest possible one.
+	CustomSort *models.CustomField
+	Sort       TaskSort
+	Desc       bool
+	After      *TaskCursor
+	Limit      int
 }
 
 type TaskRepository interface {
 	Create(ctx context.Context, task *models.Task) error
 	GetByID(ctx context.Context, id uint) (*models.Task, error)
+	// GetIncludingArchived also preloads the project when it is archived.
+	GetIncludingArchived(ctx context.Context, id uint) (*models.Task, error)
 	Update(ctx context.Context, task *models.Task) error
-	ListByProjectID(ctx context.Context, projectID uint) ([]models.Task, error)
+	Archive(ctx context.Context, id uint, at time.Time) error
+	Restore(ctx context.Context, id uint) error
+	ArchiveByProjectID(ctx context.Context, projectID uint, at time.Time) error
+	RestoreByProjectID(ctx context.Context, projectID uint, archivedAt time.Time) error
+	ArchiveByWorkspaceID(ctx context.Context, workspaceID uint, at time.Time) error
+	RestoreByWorkspaceID(ctx context.Context, workspaceID uint, archivedAt time.Time) error
+	// ArchiveDescendants archives the live subtasks below a task at every
+	// depth. RestoreDescendants brings back those archived at archivedAt.
+	ArchiveDescendants(ctx context.Context, id uint, at time.Time) error
+	RestoreDescendants(ctx context.Context, id uint, archivedAt time.Time) error
+	// IsAncestor reports whether ancestorID is taskID itself or one of the
+	// tasks above it.
+	IsAncestor(ctx context.Context, ancestorID, taskID uint) (bool, error)
+	// Progress counts the live subtasks below each of the given tasks. Tasks
+	// without subtasks are left out.
+	Progress(ctx context.Context, ids []uint) ([]TaskProgressCount, error)
+	// List returns the tasks matching filter in the requested order, starting
+	// after filter.After when it is set.
+	List(ctx context.Context, filter TaskFilter) ([]models.Task, error)
+	// CountByProject counts the tasks matching filter per project. Ordering,
+	// cursor and limit are ignored.
+	CountByProject(ctx context.Context, filter TaskFilter) ([]ProjectTaskCount, error)
+	ListByAssigneeID(ctx context.Context, assigneeID uint) ([]models.Task, error)
+	// ListInvalid returns every task, archived ones included, whose priority
+	// is not one of priorities or whose status is not part of its project's
+	// workflow. Projects without a custom workflow use defaultStatuses.
+	ListInvalid(ctx context.Context, priorities []models.TaskPriority, defaultStatuses []models.TaskStatus) ([]models.Task, error)
+	// Repair overwrites a task's status and priority, even while archived,
+	// without touching anything else.
+	Repair(ctx context.Context, id uint, status models.TaskStatus, priority models.TaskPriority) error
+	// ListNewlyOverdue returns the live, uncompleted tasks due at or before now
+	// that have not been flagged as overdue yet.
+	ListNewlyOverdue(ctx context.Context, now time.Time) ([]models.Task, error)
+	// MarkOverdue flags a task as overdue unless it already is. It reports
+	// whether the task was flagged by this call.
+	MarkOverdue(ctx context.Context, id uint, at time.Time) (bool, error)
+	// SyncCompletion sets completed_at to at on the project's tasks in one of
+	// doneStatuses that lack it, and clears it on every other task.
+	SyncCompletion(ctx context.Context, projectID uint, doneStatuses []models.TaskStatus, at time.Time) error
+	// ListStatusesInUse returns the distinct statuses held by the project's
+	// tasks, archived ones included.
+	ListStatusesInUse(ctx context.Context, projectID uint) ([]models.TaskStatus, error)
+}
+
+type TaskCommentRepository interface {
+	Create(ctx context.Context, comment *models.TaskComment) error
+	// GetByID returns a comment with its author and mentions.
+	GetByID(ctx context.Context, id uint) (*models.TaskComment, error)
+	Update(ctx context.Context, comment *models.TaskComment) error
+	Delete(ctx context.Context, id uint) error
+	// ListByTaskID returns a page of the task's comments, oldest first, along
+	// with the total number of comments.
+	ListByTaskID(ctx context.Context, taskID uint, limit, offset int) ([]models.TaskComment, int64, error)
+	// ReplaceMentions sets the users mentioned in a comment.
+	ReplaceMentions(ctx context.Context, commentID uint, userIDs []uint) error
+	CreateEdit(ctx context.Context, edit *models.TaskCommentEdit) error
+	// ListEdits returns a comment's edits, newest first.
+	ListEdits(ctx context.Context, commentID uint) ([]models.TaskCommentEdit, error)
+}
+
+type LabelRepository interface {
+	Create(ctx context.Context, label *models.Label) error
+	GetByID(ctx context.Context, id uint) (*models.Label, error)
+	// GetByName finds a workspace's label by name, ignoring case.
+	GetByName(ctx context.Context, workspaceID uint, name string) (*models.Label, error)
+	Update(ctx context.Context, label *models.Label) error
+	// Delete removes a label and detaches it from every task.
+	Delete(ctx context.Context, id uint) error
+	// ListByWorkspaceID returns a workspace's labels ordered by name.
+	ListByWorkspaceID(ctx context.Context, workspaceID uint) ([]models.Label, error)
+	// AddToTask attaches a label to a task. It reports whether the label was
+	// not attached yet.
+	AddToTask(ctx context.Context, taskID, labelID uint) (bool, error)
+	// RemoveFromTask detaches a label from a task. It reports whether the
+	// label was attached.
+	RemoveFromTask(ctx context.Context, taskID, labelID uint) (bool, error)
+}
+
+type CustomFieldRepository interface {
+	Create(ctx context.Context, field *models.CustomField) error
+	GetByID(ctx context.Context, id uint) (*models.CustomField, error)
+	Update(ctx context.Context, field *models.CustomField) error
+	// Delete removes a field together with every task's value for it.
+	Delete(ctx context.Context, id uint) error
+	// ListByProjectIDs returns the fields of the given projects ordered by
+	// position.
+	ListByProjectIDs(ctx context.Context, projectIDs []uint) ([]models.CustomField, error)
+	// ListValues returns every custom field value of the given tasks.
+	ListValues(ctx context.Context, taskIDs []uint) ([]models.CustomFieldValue, error)
+	// SetValue creates or replaces a task's value for a field.
+	SetValue(ctx context.Context, value *models.CustomFieldValue) error
+	DeleteValue(ctx context.Context, taskID, fieldID uint) error
+	// CountMissingValues counts the project's live tasks without a value for
+	// the field.
+	CountMissingValues(ctx context.Context, projectID, fieldID uint) (int64, error)
+	// CountOptionUses counts the values of a select or multi-select field
+	// that use one of options.
+	CountOptionUses(ctx context.Context, fieldID uint, options []string) (int64, error)
+}
+
+type AttachmentRepository interface {
+	Create(ctx context.Context, attachment *models.Attachment) error
+	GetByID(ctx context.Context, id uint) (*models.Attachment, error)
+	Delete(ctx context.Context, id uint) error
+	// ListByTaskID returns a task's attachments, oldest first, with their
+	// uploaders.
+	ListByTaskID(ctx context.Context, taskID uint) ([]models.Attachment, error)
+	// ListArchived returns up to limit attachments of tasks that were
+	// archived before the given time, directly or through their project or
+	// workspace.
+	ListArchived(ctx context.Context, before time.Time, limit int) ([]models.Attachment, error)
+}
+
+// SearchResultType names the kind of record a search hit refers to.
+type SearchResultType string
+
+const (
+	SearchResultTask    SearchResultType = "task"
+	SearchResultComment SearchResultType = "comment"
+	SearchResultProject SearchResultType = "project"
+)
+
+// SearchFilter selects the records a full-text search looks through.
+type SearchFilter struct {
+	// Query uses web search syntax: quoted phrases, OR and -word.
+	Query string
+	// MemberID restricts results to workspaces this user is a member of;
+	// 0 searches every workspace.
+	MemberID    uint
+	WorkspaceID uint
+	// Types limits the kinds of records searched; empty searches them all.
+	Types  []SearchResultType
+	Limit  int
+	Offset int
+}
+
+// SearchHit is a record matching a search. TaskID is set for tasks and
+// comments, and Title is the task title or project name. Snippet is
+// HTML-escaped text around the matches, which are wrapped in <mark> tags.
+type SearchHit struct {
+	Type        SearchResultType
+	ID          uint
+	TaskID      *uint
+	ProjectID   uint
+	WorkspaceID uint
+	Title       string
+	Snippet     string
+	Rank        float64
+}
+
+// SearchRepository runs full-text searches over live tasks, comments and
+// projects.
+type SearchRepository interface {
+	// Search returns one page of hits, best match first, and the total number
+	// of hits.
+	Search(ctx context.Context, filter SearchFilter) ([]SearchHit, int64, error)
+}
+
+type TaskDependencyRepository interface {
+	Create(ctx context.Context, dependency *models.TaskDependency) error
+	// Get returns the direct link from blockingID to blockedID.
+	Get(ctx context.Context, blockingID, blockedID uint) (*models.TaskDependency, error)
+	// Delete removes the link between two tasks, whichever way it points, and
+	// returns it. It returns gorm.ErrRecordNotFound when there is none.
+	Delete(ctx context.Context, taskID, otherID uint) (*models.TaskDependency, error)
+	// ListByTaskID returns the links from and to a task whose other end is a
+	// live task, with both tasks preloaded.
+	ListByTaskID(ctx context.Context, taskID uint) ([]models.TaskDependency, error)
+	// Blocks reports whether blockingID blocks blockedID, directly or through
+	// other tasks.
+	Blocks(ctx context.Context, blockingID, blockedID uint) (bool, error)
+	// ListBlocked returns those of ids that depend on a live task that is not
+	// completed.
+	ListBlocked(ctx context.Context, ids []uint) ([]uint, error)
+	// LockWorkspace serializes dependency changes within a workspace until the
+	// surrounding transaction ends.
+	LockWorkspace(ctx context.Context, workspaceID uint) error
+}
+
+type WorkflowRepository interface {
+	// Get returns the project's custom workflow, or nil when it uses the
+	// default one.
+	Get(ctx context.Context, projectID uint) (*models.Workflow, error)
+	// Replace swaps the project's workflow definition for the given statuses
+	// and transitions. Passing none resets the project to the default workflow.
+	Replace(ctx context.Context, projectID uint, statuses []models.WorkflowStatus, transitions []models.WorkflowTransition) error
+}
+
+// ActivityFilter narrows down an activity feed query. Zero-valued fields are
+// ignored; exactly one of ProjectID or WorkspaceID is expected to be set.
+type ActivityFilter struct {
+	ProjectID   uint
+	WorkspaceID uint
+	UserID      uint
+	ChangeType  string
+	Since       *time.Time
+	Until       *time.Time
+	Limit       int
+	Offset      int
 }
 
 type TaskHistoryRepository interface {
 	Create(ctx context.Context, history *models.TaskHistory) error
 	ListByTaskID(ctx context.Context, taskID uint) ([]models.TaskHistory, error)
+	// ListActivity returns the page of history entries matching filter, newest
+	// first, along with the total number of matching entries.
+	ListActivity(ctx context.Context, filter ActivityFilter) ([]models.TaskHistory, int64, error)
+}
+
+type AuditLogRepository interface {
+	Create(ctx context.Context, entry *models.AuditLog) error
+	// List returns a page of audit entries, newest first, along with the total
+	// number of entries.
+	List(ctx context.Context, limit, offset int) ([]models.AuditLog, int64, error)
+}
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *models.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	// MarkReplaced revokes the token with the given ID and links it to its
+	// replacement. It reports false if the token had already been revoked.
+	MarkReplaced(ctx context.Context, id, replacedByID uint) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllForUser(ctx context.Context, userID uint) error
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+type RevokedTokenRepository interface {
+	Create(ctx context.Context, token *models.RevokedToken) error
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *models.Webhook) error
+	GetByID(ctx context.Context, id uint) (*models.Webhook, error)
+	Update(ctx context.Context, webhook *models.Webhook) error
+	// Delete removes a webhook together with its deliveries.
+	Delete(ctx context.Context, id uint) error
+	ListByWorkspaceID(ctx context.Context, workspaceID uint) ([]models.Webhook, error)
+
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	GetDelivery(ctx context.Context, id uint) (*models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// ListDeliveries returns a page of a webhook's deliveries, newest first.
+	ListDeliveries(ctx context.Context, webhookID uint, limit, offset int) ([]models.WebhookDelivery, int64, error)
+	// ClaimDueDeliveries returns up to limit pending deliveries due at now,
+	// oldest first, and pushes their next attempt back by lease so that no
+	// other worker picks them up while they are being sent.
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+}
+
+type NotificationRepository interface {
+	// GetPreference returns gorm.ErrRecordNotFound for users who have not
+	// saved any preferences.
+	GetPreference(ctx context.Context, userID uint) (*models.NotificationPreference, error)
+	SavePreference(ctx context.Context, preference *models.NotificationPreference) error
+
+	CreateDigestItem(ctx context.Context, item *models.EmailDigestItem) error
+	// ListDigestRecipients returns the IDs of users with digest items waiting.
+	ListDigestRecipients(ctx context.Context) ([]uint, error)
+	// ListDigestItems returns a user's waiting digest items, oldest first.
+	ListDigestItems(ctx context.Context, userID uint) ([]models.EmailDigestItem, error)
+	DeleteDigestItems(ctx context.Context, ids []uint) error
+
+	Create(ctx context.Context, notification *models.Notification) error
+	GetByID(ctx context.Context, id uint) (*models.Notification, error)
+	// ListByUserID returns a user's notifications, newest first, optionally
+	// only the unread ones, with the total count.
+	ListByUserID(ctx context.Context, userID uint, unreadOnly bool, limit, offset int) ([]models.Notification, int64, error)
+	CountUnread(ctx context.Context, userID uint) (int64, error)
+	// MarkRead marks a notification read at at, unless it already was.
+	MarkRead(ctx context.Context, id uint, at time.Time) error
+	// MarkAllRead marks every unread notification of a user read at at and
+	// returns how many there were.
+	MarkAllRead(ctx context.Context, userID uint, at time.Time) (int64, error)
 }
 
 type Repository interface {
 	Users() UserRepository
 	Workspaces() WorkspaceRepository
+	WorkspaceMembers() WorkspaceMemberRepository
 	Projects() ProjectRepository
 	Tasks() TaskRepository
+	Workflows() WorkflowRepository
+	TaskDependencies() TaskDependencyRepository
+	Labels() LabelRepository
+	CustomFields() CustomFieldRepository
+	Attachments() AttachmentRepository
+	Search() SearchRepository
+	Webhooks() WebhookRepository
+	Notifications() NotificationRepository
+	TaskComments() TaskCommentRepository
 	TaskHistory() TaskHistoryRepository
+	AuditLogs() AuditLogRepository
+	RefreshTokens() RefreshTokenRepository
+	RevokedTokens() RevokedTokenRepository
+
+	// Transaction runs fn against a Repository bound to a single database
+	// transaction. The transaction is committed if fn returns nil and rolled
+	// back otherwise.
+	Transaction(ctx context.Context, fn func(tx Repository) error) error
 }
@@ -5,33 +5,59 @@ import (
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/services"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/auth"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/events"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/mail"
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/middleware"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/storage"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func SetupRouter(repo repository.Repository) *gin.Engine {
+func SetupRouter(repo repository.Repository, keys auth.KeyManager, store storage.Storage, attachmentLimits services.AttachmentLimits, mailer mail.Mailer, hub *events.Hub) *gin.Engine {
 	r := gin.Default()
 
 	// Swagger documentation
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Initialize services
+	notificationService := services.NewNotificationService(repo, mailer)
 	workspaceService := services.NewWorkspaceService(repo)
 	projectService := services.NewProjectService(repo)
-	taskService := services.NewTaskService(repo)
+	taskService := services.NewTaskService(repo, hub, notificationService)
+	historyService := services.NewHistoryService(repo)
+	workflowService := services.NewWorkflowService(repo)
+	commentService := services.NewCommentService(repo, notificationService)
+	labelService := services.NewLabelService(repo)
+	customFieldService := services.NewCustomFieldService(repo)
+	attachmentService := services.NewAttachmentService(repo, store, attachmentLimits)
+	searchService := services.NewSearchService(repo)
+	eventService := services.NewEventService(repo, hub)
+	webhookService := services.NewWebhookService(repo)
+	userService := services.NewUserService(repo)
+	tokenService := services.NewTokenService(repo, keys)
 
 	// Initialize controllers
-	authController := controllers.NewAuthController(repo)
-	managerController := controllers.NewManagerController(workspaceService, projectService, taskService)
-	devController := controllers.NewDevController(taskService, projectService)
+	authController := controllers.NewAuthController(repo, tokenService)
+	managerController := controllers.NewManagerController(workspaceService, projectService, taskService, historyService, workflowService, labelService, customFieldService, webhookService)
+	devController := controllers.NewDevController(taskService, projectService, historyService, workspaceService, workflowService, commentService, labelService, customFieldService, attachmentService)
+	adminController := controllers.NewAdminController(userService)
+	searchController := controllers.NewSearchController(searchService)
+	eventController := controllers.NewEventController(eventService)
+	notificationController := controllers.NewNotificationController(notificationService)
+
+	authMiddleware := middleware.AuthMiddleware(repo.Users(), keys, repo.RevokedTokens())
+
+	// Public keys for verifying tokens issued by this API
+	r.GET("/.well-known/jwks.json", authController.JWKS)
 
 	// Public routes
 	public := r.Group("/api")
 	{
 		public.POST("/register", authController.Register)
 		public.POST("/login", authController.Login)
+		public.POST("/token/refresh", authController.RefreshToken)
 		public.GET("/ping", func(c *gin.Context) {
 			c.JSON(200, gin.H{"message": "pong"})
 		})
@@ -39,22 +65,68 @@ func SetupRouter(repo repository.Repository) *gin.Engine {
 
 	// Protected routes (require authentication)
 	protected := r.Group("/api")
-	protected.Use(middleware.AuthMiddleware())
+	protected.Use(authMiddleware)
 	{
-		protected.GET("/profile", authController.GetProfile)
+		// Must stay reachable while a forced password reset is pending
+		protected.PUT("/profile/password", authController.ChangePassword)
+		protected.POST("/logout", authController.Logout)
+
+		protected.GET("/profile", middleware.PasswordResetMiddleware(), authController.GetProfile)
+		protected.GET("/profile/notifications", middleware.PasswordResetMiddleware(), notificationController.GetPreferences)
+		protected.PUT("/profile/notifications", middleware.PasswordResetMiddleware(), notificationController.UpdatePreferences)
+		protected.GET("/search", middleware.PasswordResetMiddleware(), searchController.Search)
+
+		protected.GET("/notifications", middleware.PasswordResetMiddleware(), notificationController.ListNotifications)
+		protected.GET("/notifications/unread-count", middleware.PasswordResetMiddleware(), notificationController.CountUnreadNotifications)
+		protected.POST("/notifications/read-all", middleware.PasswordResetMiddleware(), notificationController.MarkAllNotificationsRead)
+		protected.POST("/notifications/:id/read", middleware.PasswordResetMiddleware(), notificationController.MarkNotificationRead)
 	}
 
 	// Manager and Admin routes
 	manager := r.Group("/api/manager")
-	manager.Use(middleware.AuthMiddleware())
+	manager.Use(authMiddleware)
+	manager.Use(middleware.PasswordResetMiddleware())
 	manager.Use(middleware.RoleMiddleware(models.RoleAdmin, models.RoleManager))
 	{
 		// Workspace management
 		manager.POST("/workspaces", managerController.CreateWorkspace)
+		manager.PUT("/workspaces/:workspace_id", managerController.UpdateWorkspace)
+		manager.DELETE("/workspaces/:workspace_id", managerController.ArchiveWorkspace)
+		manager.POST("/workspaces/:workspace_id/restore", managerController.RestoreWorkspace)
 		manager.GET("/workspaces/:workspace_id/projects", managerController.ListWorkspaceProjects)
+		manager.GET("/workspaces/:workspace_id/activity", managerController.ListWorkspaceActivity)
+
+		// Workspace membership
+		manager.POST("/workspaces/:workspace_id/members", managerController.InviteMember)
+		manager.GET("/workspaces/:workspace_id/members", managerController.ListMembers)
+		manager.PUT("/workspaces/:workspace_id/members/:user_id", managerController.UpdateMemberRole)
+		manager.DELETE("/workspaces/:workspace_id/members/:user_id", managerController.RemoveMember)
+
+		// Label catalog
+		manager.POST("/workspaces/:workspace_id/labels", managerController.CreateLabel)
+		manager.PUT("/labels/:id", managerController.UpdateLabel)
+		manager.DELETE("/labels/:id", managerController.DeleteLabel)
 
 		// Project management
 		manager.POST("/projects", managerController.CreateProject)
+		manager.PUT("/projects/:id", managerController.UpdateProject)
+		manager.DELETE("/projects/:id", managerController.ArchiveProject)
+		manager.POST("/projects/:id/restore", managerController.RestoreProject)
+		manager.PUT("/projects/:id/workflow", managerController.UpdateWorkflow)
+		manager.DELETE("/projects/:id/workflow", managerController.ResetWorkflow)
+
+		// Custom fields
+		manager.POST("/projects/:id/fields", managerController.CreateCustomField)
+		manager.PUT("/fields/:id", managerController.UpdateCustomField)
+		manager.DELETE("/fields/:id", managerController.DeleteCustomField)
+
+		// Webhooks
+		manager.GET("/workspaces/:workspace_id/webhooks", managerController.ListWebhooks)
+		manager.POST("/workspaces/:workspace_id/webhooks", managerController.CreateWebhook)
+		manager.PUT("/webhooks/:id", managerController.UpdateWebhook)
+		manager.DELETE("/webhooks/:id", managerController.DeleteWebhook)
+		manager.GET("/webhooks/:id/deliveries", managerController.ListWebhookDeliveries)
+		manager.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", managerController.RedeliverWebhook)
 
 		// Task assignment
 		manager.PUT("/tasks/:id/assign", managerController.AssignTask)
@@ -62,27 +134,72 @@ func SetupRouter(repo repository.Repository) *gin.Engine {
 
 	// Developer routes (all authenticated users can access)
 	dev := r.Group("/api/dev")
-	dev.Use(middleware.AuthMiddleware())
+	dev.Use(authMiddleware)
+	dev.Use(middleware.PasswordResetMiddleware())
 	{
+		// Workspaces the caller belongs to
+		dev.GET("/workspaces", devController.ListMyWorkspaces)
+		dev.GET("/workspaces/:workspace_id/labels", devController.ListLabels)
+		dev.GET("/me/tasks", devController.ListMyTasks)
+
 		// Project viewing (must come before tasks routes to avoid conflict)
 		dev.GET("/projects/:id", devController.GetProject)
 		dev.GET("/projects/:id/tasks", devController.ListProjectTasks)
+		dev.GET("/projects/:id/activity", devController.ListProjectActivity)
+		dev.GET("/projects/:id/workflow", devController.GetWorkflow)
+		dev.GET("/projects/:id/fields", devController.ListCustomFields)
 
 		// Task operations
 		dev.POST("/tasks", devController.CreateTask)
 		dev.GET("/tasks/:id", devController.GetTask)
 		dev.PUT("/tasks/:id", devController.UpdateTask)
+		dev.DELETE("/tasks/:id", devController.DeleteTask)
+		dev.POST("/tasks/:id/restore", devController.RestoreTask)
+		dev.GET("/tasks/:id/history", devController.GetTaskHistory)
+		dev.GET("/tasks/:id/children", devController.ListSubtasks)
+		dev.PUT("/tasks/:id/parent", devController.MoveTask)
+		dev.GET("/tasks/:id/dependencies", devController.ListDependencies)
+		dev.POST("/tasks/:id/dependencies", devController.AddDependency)
+		dev.DELETE("/tasks/:id/dependencies/:other_id", devController.RemoveDependency)
+		dev.PUT("/tasks/:id/labels/:label_id", devController.AttachLabel)
+		dev.DELETE("/tasks/:id/labels/:label_id", devController.DetachLabel)
+
+		// Task attachments
+		dev.GET("/tasks/:id/attachments", devController.ListAttachments)
+		dev.POST("/tasks/:id/attachments", devController.UploadAttachment)
+		dev.GET("/tasks/:id/attachments/:attachment_id", devController.DownloadAttachment)
+		dev.DELETE("/tasks/:id/attachments/:attachment_id", devController.DeleteAttachment)
+
+		// Task comments
+		dev.GET("/tasks/:id/comments", devController.ListComments)
+		dev.POST("/tasks/:id/comments", devController.CreateComment)
+		dev.PUT("/tasks/:id/comments/:comment_id", devController.UpdateComment)
+		dev.DELETE("/tasks/:id/comments/:comment_id", devController.DeleteComment)
+		dev.GET("/tasks/:id/comments/:comment_id/edits", devController.ListCommentEdits)
 	}
 
+	// Project event stream. Browsers' EventSource cannot send headers, so the
+	// access token may also be passed in the query string here.
+	r.GET("/api/dev/projects/:id/events",
+		middleware.QueryTokenMiddleware(), authMiddleware, middleware.PasswordResetMiddleware(),
+		eventController.StreamProjectEvents)
+
 	// Admin only routes
 	admin := r.Group("/api/admin")
-	admin.Use(middleware.AuthMiddleware())
+	admin.Use(authMiddleware)
+	admin.Use(middleware.PasswordResetMiddleware())
 	admin.Use(middleware.RoleMiddleware(models.RoleAdmin))
 	{
-		// Add admin-only endpoints here
-		admin.GET("/users", func(c *gin.Context) {
-			c.JSON(200, gin.H{"message": "admin endpoint"})
-		})
+		// User management
+		admin.GET("/users", adminController.ListUsers)
+		admin.GET("/users/:id", adminController.GetUser)
+		admin.PUT("/users/:id/role", adminController.ChangeUserRole)
+		admin.POST("/users/:id/disable", adminController.DisableUser)
+		admin.POST("/users/:id/enable", adminController.EnableUser)
+		admin.POST("/users/:id/reset-password", adminController.ResetUserPassword)
+		admin.DELETE("/users/:id", adminController.DeleteUser)
+
+		admin.GET("/audit-logs", adminController.ListAuditLogs)
 	}
 
 	return r
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+	"gorm.io/gorm"
+)
+
+// Actor identifies the authenticated user on whose behalf a service call runs.
+type Actor struct {
+	UserID uint
+	Role   models.UserRole
+}
+
+func (a Actor) IsAdmin() bool {
+	return a.Role == models.RoleAdmin
+}
+
+// workspaceRoleRank orders workspace roles from least to most privileged.
+var workspaceRoleRank = map[models.WorkspaceRole]int{
+	models.WorkspaceRoleViewer:  1,
+	models.WorkspaceRoleDev:     2,
+	models.WorkspaceRoleManager: 3,
+	models.WorkspaceRoleOwner:   4,
+}
+
+// authorizeWorkspace checks that actor holds at least minRole in the workspace.
+// Admins always pass. Callers who are not members get an ErrNotFound for
+// entity, so the existence of other tenants' resources is not revealed;
+// members whose role is too low get ErrForbidden.
+func authorizeWorkspace(ctx context.Context, repo repository.Repository, actor Actor, workspaceID uint, minRole models.WorkspaceRole, entity string) error {
+	if actor.IsAdmin() {
+		return nil
+	}
+
+	member, err := repo.WorkspaceMembers().Get(ctx, workspaceID, actor.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%s %w", entity, ErrNotFound)
+		}
+		return fmt.Errorf("failed to load workspace membership: %w", err)
+	}
+
+	if workspaceRoleRank[member.Role] < workspaceRoleRank[minRole] {
+		return fmt.Errorf("%w: requires workspace role %s or higher", ErrForbidden, minRole)
+	}
+	return nil
+}
+
+// actorWorkspaceRole returns the actor's role in a workspace they are known to
+// have access to. Admins are treated as owners.
+func actorWorkspaceRole(ctx context.Context, repo repository.Repository, actor Actor, workspaceID uint) (models.WorkspaceRole, error) {
+	if actor.IsAdmin() {
+		return models.WorkspaceRoleOwner, nil
+	}
+	member, err := repo.WorkspaceMembers().Get(ctx, workspaceID, actor.UserID)
+	if err != nil {
+		return "", fmt.Errorf("failed to load workspace membership: %w", err)
+	}
+	return member.Role, nil
+}
+
+// loadWorkspace fetches a workspace the actor may access with at least minRole.
+func loadWorkspace(ctx context.Context, repo repository.Repository, actor Actor, workspaceID uint, minRole models.WorkspaceRole) (*models.Workspace, error) {
+	workspace, err := repo.Workspaces().GetByID(ctx, workspaceID)
+	if err != nil {
+		return nil, lookupError("workspace", err)
+	}
+	if err := authorizeWorkspace(ctx, repo, actor, workspace.ID, minRole, "workspace"); err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
+
+// loadProject fetches a project the actor may access with at least minRole in
+// its workspace.
+func loadProject(ctx context.Context, repo repository.Repository, actor Actor, projectID uint, minRole models.WorkspaceRole) (*models.Project, error) {
+	project, err := repo.Projects().GetByID(ctx, projectID)
+	if err != nil {
+		return nil, lookupError("project", err)
+	}
+	if err := authorizeWorkspace(ctx, repo, actor, project.WorkspaceID, minRole, "project"); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// loadTask fetches a task the actor may access with at least minRole in the
+// workspace owning its project.
+func loadTask(ctx context.Context, repo repository.Repository, actor Actor, taskID uint, minRole models.WorkspaceRole) (*models.Task, error) {
+	task, err := repo.Tasks().GetByID(ctx, taskID)
+	if err != nil {
+		return nil, lookupError("task", err)
+	}
+	if err := authorizeWorkspace(ctx, repo, actor, task.Project.WorkspaceID, minRole, "task"); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// archiveTimestamp returns the deleted_at value for an archive operation. It is
+// truncated to the database's precision so children archived along with their
+// parent can later be matched on the exact same timestamp.
+func archiveTimestamp() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// requireMember checks that userID belongs to the workspace, e.g. before a task
+// in that workspace is assigned to them.
+func requireMember(ctx context.Context, repo repository.Repository, workspaceID, userID uint) error {
+	if _, err := repo.WorkspaceMembers().Get(ctx, workspaceID, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: user %d is not a member of this workspace", ErrInvalidInput, userID)
+		}
+		return fmt.Errorf("failed to load workspace membership: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+	"gorm.io/gorm"
+)
+
+// memberKey identifies a workspace membership in fakeRepository.
+type memberKey struct{ workspaceID, userID uint }
+
+// fakeRepository keeps workspaces, members, projects and tasks in memory.
+// Calls to any other part of the repository panic, so a test fails loudly if
+// the code under test reaches further than expected.
+type fakeRepository struct {
+	repository.Repository
+	workspaces map[uint]*models.Workspace
+	members    map[memberKey]*models.WorkspaceMember
+	projects   map[uint]*models.Project
+	tasks      map[uint]*models.Task
+}
+
+func (r *fakeRepository) Workspaces() repository.WorkspaceRepository {
+	return fakeWorkspaces{r: r}
+}
+
+func (r *fakeRepository) WorkspaceMembers() repository.WorkspaceMemberRepository {
+	return fakeMembers{r: r}
+}
+
+func (r *fakeRepository) Projects() repository.ProjectRepository {
+	return fakeProjects{r: r}
+}
+
+func (r *fakeRepository) Tasks() repository.TaskRepository {
+	return fakeTasks{r: r}
+}
+
+type fakeWorkspaces struct {
+	repository.WorkspaceRepository
+	r *fakeRepository
+}
+
+func (f fakeWorkspaces) GetByID(ctx context.Context, id uint) (*models.Workspace, error) {
+	if workspace, ok := f.r.workspaces[id]; ok {
+		return workspace, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type fakeMembers struct {
+	repository.WorkspaceMemberRepository
+	r *fakeRepository
+}
+
+func (f fakeMembers) Get(ctx context.Context, workspaceID, userID uint) (*models.WorkspaceMember, error) {
+	if member, ok := f.r.members[memberKey{workspaceID, userID}]; ok {
+		return member, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type fakeProjects struct {
+	repository.ProjectRepository
+	r *fakeRepository
+}
+
+func (f fakeProjects) GetByID(ctx context.Context, id uint) (*models.Project, error) {
+	if project, ok := f.r.projects[id]; ok {
+		return project, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type fakeTasks struct {
+	repository.TaskRepository
+	r *fakeRepository
+}
+
+func (f fakeTasks) GetByID(ctx context.Context, id uint) (*models.Task, error) {
+	if task, ok := f.r.tasks[id]; ok {
+		clone := *task
+		return &clone, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// Users of the two-tenant fixture.
+const (
+	aliceID   uint = 1 // Owner of workspace 1
+	bobID     uint = 2 // Owner of workspace 2, no access to workspace 1
+	viewerID  uint = 3 // Viewer in workspace 1
+	managerID uint = 4 // Manager in workspace 2 only
+)
+
+// newTenantFixture returns a repository with two workspaces, each holding one
+// project with one task.
+func newTenantFixture() *fakeRepository {
+	r := &fakeRepository{
+		workspaces: map[uint]*models.Workspace{
+			1: {ID: 1, Name: "Alice's", OwnerID: aliceID},
+			2: {ID: 2, Name: "Bob's", OwnerID: bobID},
+		},
+		members: map[memberKey]*models.WorkspaceMember{
+			{1, aliceID}:   {WorkspaceID: 1, UserID: aliceID, Role: models.WorkspaceRoleOwner},
+			{1, viewerID}:  {WorkspaceID: 1, UserID: viewerID, Role: models.WorkspaceRoleViewer},
+			{2, bobID}:     {WorkspaceID: 2, UserID: bobID, Role: models.WorkspaceRoleOwner},
+			{2, managerID}: {WorkspaceID: 2, UserID: managerID, Role: models.WorkspaceRoleManager},
+		},
+		projects: map[uint]*models.Project{
+			10: {ID: 10, Name: "Apollo", WorkspaceID: 1},
+			20: {ID: 20, Name: "Gemini", WorkspaceID: 2},
+		},
+	}
+	r.tasks = map[uint]*models.Task{
+		100: {ID: 100, Title: "Launch", ProjectID: 10, Project: *r.projects[10], Status: models.TaskStatusTodo},
+		200: {ID: 200, Title: "Dock", ProjectID: 20, Project: *r.projects[20], Status: models.TaskStatusTodo},
+	}
+	return r
+}
+
+func dev(userID uint) Actor {
+	return Actor{UserID: userID, Role: models.RoleDev}
+}
+
+func TestLoadersHideOtherTenants(t *testing.T) {
+	ctx := context.Background()
+	repo := newTenantFixture()
+
+	tests := []struct {
+		name string
+		load func() error
+		want error
+	}{
+		{"member reads workspace", func() error {
+			_, err := loadWorkspace(ctx, repo, dev(aliceID), 1, models.WorkspaceRoleViewer)
+			return err
+		}, nil},
+		{"non-member reads workspace", func() error {
+			_, err := loadWorkspace(ctx, repo, dev(bobID), 1, models.WorkspaceRoleViewer)
+			return err
+		}, ErrNotFound},
+		{"non-member reads project", func() error {
+			_, err := loadProject(ctx, repo, dev(bobID), 10, models.WorkspaceRoleViewer)
+			return err
+		}, ErrNotFound},
+		{"non-member reads task", func() error {
+			_, err := loadTask(ctx, repo, dev(bobID), 100, models.WorkspaceRoleViewer)
+			return err
+		}, ErrNotFound},
+		{"viewer reads task", func() error {
+			_, err := loadTask(ctx, repo, dev(viewerID), 100, models.WorkspaceRoleViewer)
+			return err
+		}, nil},
+		{"viewer edits task", func() error {
+			_, err := loadTask(ctx, repo, dev(viewerID), 100, models.WorkspaceRoleDev)
+			return err
+		}, ErrForbidden},
+		{"admin reads any task", func() error {
+			_, err := loadTask(ctx, repo, Actor{UserID: 99, Role: models.RoleAdmin}, 200, models.WorkspaceRoleOwner)
+			return err
+		}, nil},
+		{"missing task", func() error {
+			_, err := loadTask(ctx, repo, dev(aliceID), 999, models.WorkspaceRoleViewer)
+			return err
+		}, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.load()
+			if tt.want == nil && err != nil {
+				t.Fatalf("got error %v, want none", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCrossTenantAccess(t *testing.T) {
+	ctx := context.Background()
+	repo := newTenantFixture()
+	projects := NewProjectService(repo)
+	tasks := NewTaskService(repo, nil, nil)
+	title := "Renamed"
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"non-member reads project", func() error {
+			_, err := projects.GetProject(ctx, dev(bobID), 10)
+			return err
+		}, ErrNotFound},
+		{"non-member reads task", func() error {
+			_, err := tasks.GetTask(ctx, dev(bobID), 100)
+			return err
+		}, ErrNotFound},
+		{"non-member updates task", func() error {
+			_, err := tasks.UpdateTask(ctx, dev(bobID), 100, UpdateTaskInput{Title: &title})
+			return err
+		}, ErrNotFound},
+		{"viewer updates task", func() error {
+			_, err := tasks.UpdateTask(ctx, dev(viewerID), 100, UpdateTaskInput{Title: &title})
+			return err
+		}, ErrForbidden},
+		{"manager creates project in another workspace", func() error {
+			_, err := projects.CreateProject(ctx, Actor{UserID: managerID, Role: models.RoleManager}, "Mercury", 1)
+			return err
+		}, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, tt.want) {
+				t.Fatalf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if repo.tasks[100].Title != "Launch" {
+		t.Errorf("task title changed to %q", repo.tasks[100].Title)
+	}
+}
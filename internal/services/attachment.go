@@ -0,0 +1,308 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/storage"
+)
+
+const (
+	maxAttachmentNameLength = 255
+	// attachmentCleanupBatch is how many attachments the cleanup job purges
+	// per query.
+	attachmentCleanupBatch = 100
+)
+
+// AttachmentLimits bounds what can be uploaded to a task.
+type AttachmentLimits struct {
+	MaxSize int64
+	// AllowedTypes lists the accepted media types, as detected from the
+	// file's contents rather than taken from the client.
+	AllowedTypes []string
+}
+
+// DefaultAttachmentLimits accepts screenshots, logs and common archives of up
+// to 10 MiB.
+var DefaultAttachmentLimits = AttachmentLimits{
+	MaxSize: 10 << 20,
+	AllowedTypes: []string{
+		"image/png",
+		"image/jpeg",
+		"image/gif",
+		"image/webp",
+		"text/plain",
+		"application/pdf",
+		"application/zip",
+		"application/x-gzip",
+	},
+}
+
+type AttachmentService struct {
+	repo   repository.Repository
+	store  storage.Storage
+	limits AttachmentLimits
+}
+
+func NewAttachmentService(repo repository.Repository, store storage.Storage, limits AttachmentLimits) *AttachmentService {
+	return &AttachmentService{repo: repo, store: store, limits: limits}
+}
+
+// MaxSize is the largest file UploadAttachment accepts, in bytes.
+func (s *AttachmentService) MaxSize() int64 {
+	return s.limits.MaxSize
+}
+
+// UploadAttachmentInput describes an uploaded file. Content must yield
+// exactly Size bytes.
+type UploadAttachmentInput struct {
+	FileName string
+	Size     int64
+	Content  io.Reader
+}
+
+func (s *AttachmentService) ListAttachments(ctx context.Context, actor Actor, taskID uint) ([]models.Attachment, error) {
+	if _, err := loadTask(ctx, s.repo, actor, taskID, models.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+	attachments, err := s.repo.Attachments().ListByTaskID(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+// UploadAttachment stores a file and attaches it to a task. The file's type is
+// detected from its first bytes and must be one of the allowed types.
+func (s *AttachmentService) UploadAttachment(ctx context.Context, actor Actor, taskID uint, input UploadAttachmentInput) (*models.Attachment, error) {
+	task, err := loadTask(ctx, s.repo, actor, taskID, models.WorkspaceRoleDev)
+	if err != nil {
+		return nil, err
+	}
+	name, err := cleanFileName(input.FileName)
+	if err != nil {
+		return nil, err
+	}
+	if input.Size <= 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidInput)
+	}
+	if input.Size > s.limits.MaxSize {
+		return nil, fmt.Errorf("%w: file is larger than %d bytes", ErrTooLarge, s.limits.MaxSize)
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(input.Content, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if !containsString(s.limits.AllowedTypes, mediaType) {
+		return nil, &ValidationError{Field: "content_type", Value: mediaType, Allowed: s.limits.AllowedTypes}
+	}
+
+	key, err := attachmentKey(task.ID)
+	if err != nil {
+		return nil, err
+	}
+	content := io.MultiReader(bytes.NewReader(head), input.Content)
+	if err := s.store.Put(ctx, key, content, input.Size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+
+	attachment := &models.Attachment{
+		TaskID:      task.ID,
+		UploaderID:  actor.UserID,
+		FileName:    name,
+		ContentType: contentType,
+		Size:        input.Size,
+		StorageKey:  key,
+	}
+	err = s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if err := tx.Attachments().Create(ctx, attachment); err != nil {
+			return fmt.Errorf("failed to create attachment: %w", err)
+		}
+		return recordTaskHistory(ctx, tx, task.ID, actor.UserID, models.HistoryChangeAttachmentAdd, nil, attachmentFields(attachment))
+	})
+	if err != nil {
+		s.discard(key)
+		return nil, err
+	}
+
+	return s.repo.Attachments().GetByID(ctx, attachment.ID)
+}
+
+// OpenAttachment returns an attachment of a task together with its contents,
+// which the caller must close.
+func (s *AttachmentService) OpenAttachment(ctx context.Context, actor Actor, taskID, attachmentID uint) (*models.Attachment, io.ReadCloser, error) {
+	_, attachment, err := s.loadAttachment(ctx, actor, taskID, attachmentID, models.WorkspaceRoleViewer)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := s.store.Get(ctx, attachment.StorageKey)
+	if errors.Is(err, storage.ErrNotExist) {
+		return nil, nil, fmt.Errorf("attachment content %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open attachment: %w", err)
+	}
+	return attachment, content, nil
+}
+
+// DeleteAttachment removes an attachment. Uploaders can delete their own
+// attachments and workspace managers can delete any.
+func (s *AttachmentService) DeleteAttachment(ctx context.Context, actor Actor, taskID, attachmentID uint) error {
+	task, attachment, err := s.loadAttachment(ctx, actor, taskID, attachmentID, models.WorkspaceRoleDev)
+	if err != nil {
+		return err
+	}
+	if attachment.UploaderID != actor.UserID {
+		role, err := actorWorkspaceRole(ctx, s.repo, actor, task.Project.WorkspaceID)
+		if err != nil {
+			return err
+		}
+		if workspaceRoleRank[role] < workspaceRoleRank[models.WorkspaceRoleManager] {
+			return fmt.Errorf("%w: only the uploader or a workspace manager can delete an attachment", ErrForbidden)
+		}
+	}
+
+	if err := removeAttachment(ctx, s.repo, attachment, actor.UserID); err != nil {
+		return err
+	}
+	s.discard(attachment.StorageKey)
+	return nil
+}
+
+// loadAttachment fetches an attachment of a task the actor may access with at
+// least minRole. Attachments of other tasks are reported as not found.
+func (s *AttachmentService) loadAttachment(ctx context.Context, actor Actor, taskID, attachmentID uint, minRole models.WorkspaceRole) (*models.Task, *models.Attachment, error) {
+	task, err := loadTask(ctx, s.repo, actor, taskID, minRole)
+	if err != nil {
+		return nil, nil, err
+	}
+	attachment, err := s.repo.Attachments().GetByID(ctx, attachmentID)
+	if err != nil {
+		return nil, nil, lookupError("attachment", err)
+	}
+	if attachment.TaskID != task.ID {
+		return nil, nil, fmt.Errorf("attachment %w", ErrNotFound)
+	}
+	return task, attachment, nil
+}
+
+// discard deletes stored contents that no attachment refers to any more. A
+// failure only leaves an unreferenced object behind, so it is logged.
+func (s *AttachmentService) discard(key string) {
+	if err := s.store.Delete(context.Background(), key); err != nil {
+		log.Printf("Attachments: failed to delete stored object %s: %v", key, err)
+	}
+}
+
+// removeAttachment deletes an attachment's record and notes it in the task's
+// history; userID is 0 when the cleanup job removes it.
+func removeAttachment(ctx context.Context, repo repository.Repository, attachment *models.Attachment, userID uint) error {
+	return repo.Transaction(ctx, func(tx repository.Repository) error {
+		if err := tx.Attachments().Delete(ctx, attachment.ID); err != nil {
+			return fmt.Errorf("failed to delete attachment: %w", err)
+		}
+		return recordTaskHistory(ctx, tx, attachment.TaskID, userID, models.HistoryChangeAttachmentRemove, attachmentFields(attachment), nil)
+	})
+}
+
+func attachmentFields(attachment *models.Attachment) map[string]interface{} {
+	return map[string]interface{}{
+		"attachment_id": attachment.ID,
+		"file_name":     attachment.FileName,
+		"size":          attachment.Size,
+	}
+}
+
+// cleanFileName keeps the last element of a client-supplied file name and
+// drops control characters.
+func cleanFileName(name string) (string, error) {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name))
+	if name == "" || name == "." || name == "/" {
+		return "", fmt.Errorf("%w: file name must not be empty", ErrInvalidInput)
+	}
+	if utf8.RuneCountInString(name) > maxAttachmentNameLength {
+		return "", fmt.Errorf("%w: file name must be at most %d characters", ErrInvalidInput, maxAttachmentNameLength)
+	}
+	return name, nil
+}
+
+// attachmentKey picks a fresh storage key for a file attached to a task.
+func attachmentKey(taskID uint) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate attachment key: %w", err)
+	}
+	return fmt.Sprintf("tasks/%d/%s", taskID, hex.EncodeToString(b)), nil
+}
+
+// StartAttachmentCleanup launches a background goroutine that periodically
+// purges the attachments of tasks archived for longer than retention, directly
+// or with their project or workspace. Until then archived tasks keep their
+// attachments so restoring them brings the files back too.
+func StartAttachmentCleanup(repo repository.Repository, store storage.Storage, interval, retention time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			<-ticker.C
+			purged, err := purgeArchivedAttachments(context.Background(), repo, store, time.Now().Add(-retention))
+			if err != nil {
+				log.Printf("Attachment cleanup: %v", err)
+			}
+			if purged > 0 {
+				log.Printf("Attachment cleanup: purged %d attachments of archived tasks", purged)
+			}
+		}
+	}()
+}
+
+// purgeArchivedAttachments deletes the attachments of tasks archived before
+// cutoff and returns how many were deleted. Stored contents are deleted first;
+// if that fails the record is kept so the next run tries again.
+func purgeArchivedAttachments(ctx context.Context, repo repository.Repository, store storage.Storage, cutoff time.Time) (int, error) {
+	purged := 0
+	for {
+		attachments, err := repo.Attachments().ListArchived(ctx, cutoff, attachmentCleanupBatch)
+		if err != nil {
+			return purged, fmt.Errorf("failed to list archived attachments: %w", err)
+		}
+		for i := range attachments {
+			if err := store.Delete(ctx, attachments[i].StorageKey); err != nil {
+				return purged, fmt.Errorf("failed to delete stored object %s: %w", attachments[i].StorageKey, err)
+			}
+			if err := removeAttachment(ctx, repo, &attachments[i], 0); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+		if len(attachments) < attachmentCleanupBatch {
+			return purged, nil
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+)
+
+const maxCommentLength = 10000
+
+var (
+	// mentionPattern matches @username where the @ does not follow a word
+	// character, so e-mail addresses are not taken for mentions.
+	mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_.-]+)`)
+	// codePattern matches fenced code blocks and inline code spans, which are
+	// skipped when looking for mentions.
+	codePattern = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`")
+)
+
+type CommentService struct {
+	repo          repository.Repository
+	notifications *NotificationService
+}
+
+// NewCommentService returns a CommentService that tells mentioned users about
+// comments through notifications, which may be nil.
+func NewCommentService(repo repository.Repository, notifications *NotificationService) *CommentService {
+	return &CommentService{repo: repo, notifications: notifications}
+}
+
+// CommentPage is a single page of a task's comments.
+type CommentPage struct {
+	Items    []models.TaskComment `json:"items"`
+	Total    int64                `json:"total"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"page_size"`
+}
+
+func (s *CommentService) ListComments(ctx context.Context, actor Actor, taskID uint, page, pageSize int) (*CommentPage, error) {
+	if _, err := loadTask(ctx, s.repo, actor, taskID, models.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+	page, pageSize = normalizePage(page, pageSize)
+
+	comments, total, err := s.repo.TaskComments().ListByTaskID(ctx, taskID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+
+	return &CommentPage{
+		Items:    comments,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// CreateComment adds a comment to a task. @username mentions of workspace
+// members are recorded with the comment.
+func (s *CommentService) CreateComment(ctx context.Context, actor Actor, taskID uint, body string) (*models.TaskComment, error) {
+	task, err := loadTask(ctx, s.repo, actor, taskID, models.WorkspaceRoleDev)
+	if err != nil {
+		return nil, err
+	}
+	if body, err = validateCommentBody(body); err != nil {
+		return nil, err
+	}
+	mentioned, err := resolveMentions(ctx, s.repo, task.Project.WorkspaceID, body)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := &models.TaskComment{
+		TaskID:   task.ID,
+		AuthorID: actor.UserID,
+		Body:     body,
+	}
+	err = s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if err := tx.TaskComments().Create(ctx, comment); err != nil {
+			return fmt.Errorf("failed to create comment: %w", err)
+		}
+		if err := tx.TaskComments().ReplaceMentions(ctx, comment.ID, mentioned); err != nil {
+			return fmt.Errorf("failed to record mentions: %w", err)
+		}
+		current := map[string]interface{}{"comment_id": comment.ID, "body": body, "mentions": mentioned}
+		return recordTaskHistory(ctx, tx, task.ID, actor.UserID, models.HistoryChangeComment, nil, current)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err = s.repo.TaskComments().GetByID(ctx, comment.ID)
+	if err != nil {
+		return nil, err
+	}
+	enqueueWebhooks(ctx, s.repo, task.Project.WorkspaceID, models.EventCommentCreated, comment)
+	s.notifyMentioned(ctx, actor, comment, mentioned)
+	return comment, nil
+}
+
+// UpdateComment replaces the body of a comment. Only its author may edit it;
+// the previous body is kept in the comment's edit history.
+func (s *CommentService) UpdateComment(ctx context.Context, actor Actor, taskID, commentID uint, body string) (*models.TaskComment, error) {
+	task, comment, err := s.loadComment(ctx, actor, taskID, commentID, models.WorkspaceRoleDev)
+	if err != nil {
+		return nil, err
+	}
+	if comment.AuthorID != actor.UserID {
+		return nil, fmt.Errorf("%w: only the author can edit a comment", ErrForbidden)
+	}
+	if body, err = validateCommentBody(body); err != nil {
+		return nil, err
+	}
+	if body == comment.Body {
+		return comment, nil
+	}
+	mentioned, err := resolveMentions(ctx, s.repo, task.Project.WorkspaceID, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only users newly mentioned by the edit are notified.
+	var newlyMentioned []uint
+	for _, userID := range mentioned {
+		if !mentions(comment, userID) {
+			newlyMentioned = append(newlyMentioned, userID)
+		}
+	}
+
+	previous := comment.Body
+	now := time.Now()
+	comment.Body = body
+	comment.EditedAt = &now
+
+	err = s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		edit := &models.TaskCommentEdit{
+			CommentID:    comment.ID,
+			EditorID:     actor.UserID,
+			PreviousBody: previous,
+		}
+		if err := tx.TaskComments().CreateEdit(ctx, edit); err != nil {
+			return fmt.Errorf("failed to record comment edit: %w", err)
+		}
+		if err := tx.TaskComments().Update(ctx, comment); err != nil {
+			return fmt.Errorf("failed to update comment: %w", err)
+		}
+		if err := tx.TaskComments().ReplaceMentions(ctx, comment.ID, mentioned); err != nil {
+			return fmt.Errorf("failed to record mentions: %w", err)
+		}
+		return recordTaskHistory(ctx, tx, task.ID, actor.UserID, models.HistoryChangeCommentEdit,
+			map[string]interface{}{"comment_id": comment.ID, "body": previous},
+			map[string]interface{}{"comment_id": comment.ID, "body": body, "mentions": mentioned})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	comment, err = s.repo.TaskComments().GetByID(ctx, comment.ID)
+	if err != nil {
+		return nil, err
+	}
+	enqueueWebhooks(ctx, s.repo, task.Project.WorkspaceID, models.EventCommentUpdated, comment)
+	s.notifyMentioned(ctx, actor, comment, newlyMentioned)
+	return comment, nil
+}
+
+// DeleteComment removes a comment. Authors can delete their own comments and
+// workspace managers can delete any comment.
+func (s *CommentService) DeleteComment(ctx context.Context, actor Actor, taskID, commentID uint) error {
+	task, comment, err := s.loadComment(ctx, actor, taskID, commentID, models.WorkspaceRoleDev)
+	if err != nil {
+		return err
+	}
+	if comment.AuthorID != actor.UserID {
+		role, err := actorWorkspaceRole(ctx, s.repo, actor, task.Project.WorkspaceID)
+		if err != nil {
+			return err
+		}
+		if workspaceRoleRank[role] < workspaceRoleRank[models.WorkspaceRoleManager] {
+			return fmt.Errorf("%w: only the author or a workspace manager can delete a comment", ErrForbidden)
+		}
+	}
+
+	err = s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if err := tx.TaskComments().Delete(ctx, comment.ID); err != nil {
+			return fmt.Errorf("failed to delete comment: %w", err)
+		}
+		previous := map[string]interface{}{"comment_id": comment.ID, "body": comment.Body}
+		return recordTaskHistory(ctx, tx, task.ID, actor.UserID, models.HistoryChangeCommentDelete, previous, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	enqueueWebhooks(ctx, s.repo, task.Project.WorkspaceID, models.EventCommentDeleted, comment)
+	return nil
+}
+
+func (s *CommentService) ListCommentEdits(ctx context.Context, actor Actor, taskID, commentID uint) ([]models.TaskCommentEdit, error) {
+	if _, _, err := s.loadComment(ctx, actor, taskID, commentID, models.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+	return s.repo.TaskComments().ListEdits(ctx, commentID)
+}
+
+// loadComment fetches a comment on a task the actor may access with at least
+// minRole. Comments on other tasks are reported as not found.
+func (s *CommentService) loadComment(ctx context.Context, actor Actor, taskID, commentID uint, minRole models.WorkspaceRole) (*models.Task, *models.TaskComment, error) {
+	task, err := loadTask(ctx, s.repo, actor, taskID, minRole)
+	if err != nil {
+		return nil, nil, err
+	}
+	comment, err := s.repo.TaskComments().GetByID(ctx, commentID)
+	if err != nil {
+		return nil, nil, lookupError("comment", err)
+	}
+	if comment.TaskID != task.ID {
+		return nil, nil, fmt.Errorf("comment %w", ErrNotFound)
+	}
+	return task, comment, nil
+}
+
+// notifyMentioned tells the given users that comment mentions them.
+func (s *CommentService) notifyMentioned(ctx context.Context, actor Actor, comment *models.TaskComment, userIDs []uint) {
+	if len(userIDs) == 0 {
+		return
+	}
+	s.notifications.notify(ctx, notification{
+		Kind:    models.NotificationMentioned,
+		ActorID: actor.UserID,
+		TaskID:  comment.TaskID,
+		Detail:  truncate(comment.Body, maxNotificationDetail),
+	}, userIDs...)
+}
+
+// mentions reports whether comment mentions the user.
+func mentions(comment *models.TaskComment, userID uint) bool {
+	for _, mention := range comment.Mentions {
+		if mention.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// validateCommentBody trims a comment body and checks its length.
+func validateCommentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return "", fmt.Errorf("%w: comment body must not be empty", ErrInvalidInput)
+	}
+	if utf8.RuneCountInString(body) > maxCommentLength {
+		return "", fmt.Errorf("%w: comment body must be at most %d characters", ErrInvalidInput, maxCommentLength)
+	}
+	return body, nil
+}
+
+// resolveMentions returns the IDs of the workspace members @mentioned in body,
+// matching usernames case-insensitively. Mentions inside code and of users
+// outside the workspace are ignored.
+func resolveMentions(ctx context.Context, repo repository.Repository, workspaceID uint, body string) ([]uint, error) {
+	matches := mentionPattern.FindAllStringSubmatch(codePattern.ReplaceAllString(body, " "), -1)
+	if len(matches) == 0 {
+		return []uint{}, nil
+	}
+
+	members, err := repo.WorkspaceMembers().ListByWorkspaceID(ctx, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workspace members: %w", err)
+	}
+	byUsername := make(map[string]uint, len(members))
+	for _, member := range members {
+		byUsername[strings.ToLower(member.User.Username)] = member.UserID
+	}
+
+	mentioned := []uint{}
+	seen := map[uint]bool{}
+	for _, match := range matches {
+		// A trailing dot usually ends the sentence rather than the username.
+		username := strings.ToLower(strings.TrimRight(match[1], "."))
+		if userID, ok := byUsername[username]; ok && !seen[userID] {
+			seen[userID] = true
+			mentioned = append(mentioned, userID)
+		}
+	}
+	return mentioned, nil
+}
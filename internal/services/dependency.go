@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+	"gorm.io/gorm"
+)
+
+// TaskDependencies lists the live tasks a task waits for and the ones waiting
+// for it.
+type TaskDependencies struct {
+	BlockedBy []models.Task `json:"blocked_by"`
+	Blocks    []models.Task `json:"blocks"`
+}
+
+func (s *TaskService) ListDependencies(ctx context.Context, actor Actor, id uint) (*TaskDependencies, error) {
+	task, err := loadTask(ctx, s.repo, actor, id, models.WorkspaceRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	dependencies, err := s.repo.TaskDependencies().ListByTaskID(ctx, task.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dependencies: %w", err)
+	}
+
+	result := &TaskDependencies{BlockedBy: []models.Task{}, Blocks: []models.Task{}}
+	for _, dependency := range dependencies {
+		if dependency.BlockedTaskID == task.ID {
+			result.BlockedBy = append(result.BlockedBy, *dependency.BlockingTask)
+		} else {
+			result.Blocks = append(result.Blocks, *dependency.BlockedTask)
+		}
+	}
+	if err := annotateTasks(ctx, s.repo, result.BlockedBy); err != nil {
+		return nil, err
+	}
+	if err := annotateTasks(ctx, s.repo, result.Blocks); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// AddDependency records that blockingID blocks blockedID. Both tasks must be
+// in the same workspace, and the link must not close a cycle.
+func (s *TaskService) AddDependency(ctx context.Context, actor Actor, blockingID, blockedID uint) (*models.TaskDependency, error) {
+	if blockingID == blockedID {
+		return nil, fmt.Errorf("%w: a task cannot depend on itself", ErrInvalidInput)
+	}
+	blocking, blocked, err := s.loadDependencyPair(ctx, actor, blockingID, blockedID)
+	if err != nil {
+		return nil, err
+	}
+
+	dependency := &models.TaskDependency{
+		BlockingTaskID: blocking.ID,
+		BlockedTaskID:  blocked.ID,
+		CreatedByID:    actor.UserID,
+	}
+	err = s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		// Concurrent inserts could otherwise each pass the cycle check and
+		// close a cycle together.
+		if err := tx.TaskDependencies().LockWorkspace(ctx, blocked.Project.WorkspaceID); err != nil {
+			return fmt.Errorf("failed to lock dependencies: %w", err)
+		}
+		if _, err := tx.TaskDependencies().Get(ctx, blocking.ID, blocked.ID); err == nil {
+			return fmt.Errorf("%w: task %d already blocks task %d", ErrConflict, blocking.ID, blocked.ID)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to load dependency: %w", err)
+		}
+		cycle, err := tx.TaskDependencies().Blocks(ctx, blocked.ID, blocking.ID)
+		if err != nil {
+			return fmt.Errorf("failed to check dependencies: %w", err)
+		}
+		if cycle {
+			return fmt.Errorf("%w: task %d already depends on task %d, the link would create a cycle", ErrUnprocessable, blocking.ID, blocked.ID)
+		}
+
+		if err := tx.TaskDependencies().Create(ctx, dependency); err != nil {
+			return fmt.Errorf("failed to create dependency: %w", err)
+		}
+		return recordDependencyHistory(ctx, tx, dependency, actor.UserID, models.HistoryChangeDependencyAdd)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dependency, nil
+}
+
+// RemoveDependency deletes the link between two tasks, whichever of them is
+// the blocking one.
+func (s *TaskService) RemoveDependency(ctx context.Context, actor Actor, taskID, otherID uint) error {
+	if _, _, err := s.loadDependencyPair(ctx, actor, taskID, otherID); err != nil {
+		return err
+	}
+
+	return s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		dependency, err := tx.TaskDependencies().Delete(ctx, taskID, otherID)
+		if err != nil {
+			return lookupError("dependency", err)
+		}
+		return recordDependencyHistory(ctx, tx, dependency, actor.UserID, models.HistoryChangeDependencyRemove)
+	})
+}
+
+// loadDependencyPair fetches both ends of a dependency, which the actor must
+// be able to edit and which must share a workspace.
+func (s *TaskService) loadDependencyPair(ctx context.Context, actor Actor, firstID, secondID uint) (*models.Task, *models.Task, error) {
+	first, err := loadTask(ctx, s.repo, actor, firstID, models.WorkspaceRoleDev)
+	if err != nil {
+		return nil, nil, err
+	}
+	second, err := loadTask(ctx, s.repo, actor, secondID, models.WorkspaceRoleDev)
+	if err != nil {
+		return nil, nil, err
+	}
+	if first.Project.WorkspaceID != second.Project.WorkspaceID {
+		return nil, nil, fmt.Errorf("%w: dependent tasks must belong to the same workspace", ErrInvalidInput)
+	}
+	return first, second, nil
+}
+
+// recordDependencyHistory writes a history entry for a dependency change to
+// both tasks it links.
+func recordDependencyHistory(ctx context.Context, repo repository.Repository, dependency *models.TaskDependency, userID uint, changeType string) error {
+	link := map[string]interface{}{
+		"blocking_task_id": dependency.BlockingTaskID,
+		"blocked_task_id":  dependency.BlockedTaskID,
+	}
+	var previous, current map[string]interface{}
+	if changeType == models.HistoryChangeDependencyRemove {
+		previous = link
+	} else {
+		current = link
+	}
+	for _, taskID := range []uint{dependency.BlockingTaskID, dependency.BlockedTaskID} {
+		if err := recordTaskHistory(ctx, repo, taskID, userID, changeType, previous, current); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requireUnblocked fails when a task depends on a live task that is still
+// open.
+func requireUnblocked(ctx context.Context, repo repository.Repository, taskID uint) error {
+	blocked, err := repo.TaskDependencies().ListBlocked(ctx, []uint{taskID})
+	if err != nil {
+		return fmt.Errorf("failed to load dependencies: %w", err)
+	}
+	if len(blocked) > 0 {
+		return fmt.Errorf("%w: task is blocked by open dependencies", ErrUnprocessable)
+	}
+	return nil
+}
+
+// attachBlocked flags the tasks that depend on an open live task.
+func attachBlocked(ctx context.Context, repo repository.Repository, tasks []models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	blocked, err := repo.TaskDependencies().ListBlocked(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to load dependencies: %w", err)
+	}
+
+	isBlocked := make(map[uint]bool, len(blocked))
+	for _, id := range blocked {
+		isBlocked[id] = true
+	}
+	for i := range tasks {
+		tasks[i].Blocked = isBlocked[tasks[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrNotFound is returned (wrapped) when a requested resource does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidInput is returned (wrapped) when a request is well-formed but
+	// carries values the service refuses to accept.
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrConflict is returned (wrapped) when a change clashes with existing state.
+	ErrConflict = errors.New("conflict")
+	// ErrUnprocessable is returned (wrapped) when a change is well-formed but
+	// not permitted by the rules of the resource, such as a task status
+	// transition its project's workflow does not allow.
+	ErrUnprocessable = errors.New("unprocessable")
+	// ErrForbidden is returned (wrapped) when the caller can see a resource but
+	// is not allowed to perform the requested action on it.
+	ErrForbidden = errors.New("forbidden")
+	// ErrUnauthenticated is returned (wrapped) when presented credentials, such
+	// as a refresh token, are invalid, expired or revoked.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrTooLarge is returned (wrapped) when an upload exceeds the size limit.
+	ErrTooLarge = errors.New("too large")
+)
+
+// ValidationError reports a field whose value is not one of the accepted ones.
+// It wraps ErrInvalidInput; controllers include Field and Allowed in the
+// response so clients can show the valid choices.
+type ValidationError struct {
+	Field   string
+	Value   string
+	Allowed []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s %q is not allowed, expected one of %s",
+		ErrInvalidInput, e.Field, e.Value, strings.Join(e.Allowed, ", "))
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidInput
+}
+
+// validatePriority checks that priority is one of the task priorities.
+func validatePriority(priority models.TaskPriority) error {
+	if priority.IsValid() {
+		return nil
+	}
+	allowed := make([]string, 0, len(models.TaskPriorities))
+	for _, p := range models.TaskPriorities {
+		allowed = append(allowed, string(p))
+	}
+	return &ValidationError{Field: "priority", Value: string(priority), Allowed: allowed}
+}
+
+// validateStatus checks that status is part of workflow.
+func validateStatus(workflow *models.Workflow, status models.TaskStatus) error {
+	if workflow.HasStatus(status) {
+		return nil
+	}
+	allowed := make([]string, 0, len(workflow.Statuses))
+	for _, name := range workflow.StatusNames() {
+		allowed = append(allowed, string(name))
+	}
+	return &ValidationError{Field: "status", Value: string(status), Allowed: allowed}
+}
+
+// lookupError converts a repository lookup failure into an error that wraps
+// ErrNotFound when the record is missing, e.g. "task not found".
+func lookupError(entity string, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%s %w", entity, ErrNotFound)
+	}
+	return fmt.Errorf("failed to load %s: %w", entity, err)
+}
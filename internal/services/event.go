@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/events"
+)
+
+type EventService struct {
+	repo repository.Repository
+	hub  *events.Hub
+}
+
+func NewEventService(repo repository.Repository, hub *events.Hub) *EventService {
+	return &EventService{repo: repo, hub: hub}
+}
+
+// Subscribe starts streaming a project's events to a workspace member. See
+// events.Hub.Subscribe for how lastEventID resumes an earlier stream.
+func (s *EventService) Subscribe(ctx context.Context, actor Actor, projectID uint, lastEventID uint64) (*events.Subscription, []events.Event, bool, error) {
+	if _, err := loadProject(ctx, s.repo, actor, projectID, models.WorkspaceRoleViewer); err != nil {
+		return nil, nil, false, err
+	}
+	sub, missed, resumed := s.hub.Subscribe(projectID, lastEventID)
+	return sub, missed, resumed, nil
+}
+
+// Authorize checks that an open stream may continue: the access token it was
+// opened with has not been revoked, the user is still enabled and the project
+// is still visible to them.
+func (s *EventService) Authorize(ctx context.Context, actor Actor, tokenID string, projectID uint) error {
+	revoked, err := s.repo.RevokedTokens().IsRevoked(ctx, tokenID)
+	if err != nil {
+		return fmt.Errorf("failed to check token: %w", err)
+	}
+	if revoked {
+		return fmt.Errorf("%w: token has been revoked", ErrUnauthenticated)
+	}
+	user, err := s.repo.Users().GetByID(ctx, actor.UserID)
+	if err != nil {
+		return lookupError("user", err)
+	}
+	if user.IsDisabled() {
+		return fmt.Errorf("%w: account disabled", ErrUnauthenticated)
+	}
+	_, err = loadProject(ctx, s.repo, Actor{UserID: user.ID, Role: user.Role}, projectID, models.WorkspaceRoleViewer)
+	return err
+}
+
+// committedContext returns the context for a side effect of a change that has
+// already been committed, such as a notification or a webhook delivery. The
+// request may end before the side effect runs, but the change it made still
+// happened, so the request's cancellation is not passed on. For the same
+// reason side effects log their failures rather than return them: the caller
+// can no longer undo the change.
+func committedContext(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
+
+// publishTask pushes a task event to the stream of the task's project and to
+// the webhooks of its workspace.
+func (s *TaskService) publishTask(ctx context.Context, eventType string, workspaceID uint, task *models.Task) {
+	publishTaskEvent(ctx, s.repo, s.events, eventType, workspaceID, task)
+}
+
+// publishTaskEvent is publishTask for callers without a TaskService, such as
+// background jobs. hub may be nil.
+func publishTaskEvent(ctx context.Context, repo repository.Repository, hub *events.Hub, eventType string, workspaceID uint, task *models.Task) {
+	hub.Publish(task.ProjectID, eventType, task)
+	enqueueWebhooks(ctx, repo, workspaceID, eventType, task)
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+)
+
+type HistoryService struct {
+	repo repository.Repository
+}
+
+func NewHistoryService(repo repository.Repository) *HistoryService {
+	return &HistoryService{repo: repo}
+}
+
+// ActivityPage is a single page of an activity feed.
+type ActivityPage struct {
+	Items    []models.TaskHistory `json:"items"`
+	Total    int64                `json:"total"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"page_size"`
+}
+
+// ActivityQuery holds the user-supplied filters for an activity feed.
+type ActivityQuery struct {
+	UserID     uint
+	ChangeType string
+	Since      *time.Time
+	Until      *time.Time
+	Page       int
+	PageSize   int
+}
+
+func (s *HistoryService) ListTaskHistory(ctx context.Context, actor Actor, taskID uint) ([]models.TaskHistory, error) {
+	if _, err := loadTask(ctx, s.repo, actor, taskID, models.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+	return s.repo.TaskHistory().ListByTaskID(ctx, taskID)
+}
+
+func (s *HistoryService) ListProjectActivity(ctx context.Context, actor Actor, projectID uint, query ActivityQuery) (*ActivityPage, error) {
+	if _, err := loadProject(ctx, s.repo, actor, projectID, models.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+	return s.listActivity(ctx, repository.ActivityFilter{ProjectID: projectID}, query)
+}
+
+func (s *HistoryService) ListWorkspaceActivity(ctx context.Context, actor Actor, workspaceID uint, query ActivityQuery) (*ActivityPage, error) {
+	if _, err := loadWorkspace(ctx, s.repo, actor, workspaceID, models.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+	return s.listActivity(ctx, repository.ActivityFilter{WorkspaceID: workspaceID}, query)
+}
+
+func (s *HistoryService) listActivity(ctx context.Context, filter repository.ActivityFilter, query ActivityQuery) (*ActivityPage, error) {
+	query.Page, query.PageSize = normalizePage(query.Page, query.PageSize)
+
+	filter.UserID = query.UserID
+	filter.ChangeType = query.ChangeType
+	filter.Since = query.Since
+	filter.Until = query.Until
+	filter.Limit = query.PageSize
+	filter.Offset = (query.Page - 1) * query.PageSize
+
+	items, total, err := s.repo.TaskHistory().ListActivity(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list activity: %w", err)
+	}
+
+	return &ActivityPage{
+		Items:    items,
+		Total:    total,
+		Page:     query.Page,
+		PageSize: query.PageSize,
+	}, nil
+}
+
+// taskFields captures the user-editable fields of a task, keyed by their JSON
+// names, so that two versions of a task can be compared field by field.
+func taskFields(task *models.Task) map[string]interface{} {
+	var assigneeID, parentID interface{}
+	if task.AssigneeID != nil {
+		assigneeID = *task.AssigneeID
+	}
+	if task.ParentID != nil {
+		parentID = *task.ParentID
+	}
+	return map[string]interface{}{
+		"title":       task.Title,
+		"description": task.Description,
+		"status":      task.Status,
+		"priority":    task.Priority,
+		"assignee_id": assigneeID,
+		"parent_id":   parentID,
+		"start_date":  formatTime(task.StartDate),
+		"due_date":    formatTime(task.DueDate),
+	}
+}
+
+// formatTime renders an optional time for a history entry.
+func formatTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// diffFields returns the previous and new values of every field that differs
+// between before and after. Both maps are empty when nothing changed.
+func diffFields(before, after map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	previous := map[string]interface{}{}
+	current := map[string]interface{}{}
+	for field, newValue := range after {
+		if oldValue := before[field]; oldValue != newValue {
+			previous[field] = oldValue
+			current[field] = newValue
+		}
+	}
+	return previous, current
+}
+
+// recordTaskHistory writes a history row for a task mutation. It is meant to be
+// called with the transactional repository used for the mutation itself so the
+// task and its history are committed together.
+func recordTaskHistory(ctx context.Context, repo repository.Repository, taskID, userID uint, changeType string, previous, current map[string]interface{}) error {
+	entry := &models.TaskHistory{
+		TaskID:     taskID,
+		ChangeType: changeType,
+	}
+	if userID != 0 {
+		entry.UserID = &userID
+	}
+
+	if previous != nil {
+		encoded, err := json.Marshal(previous)
+		if err != nil {
+			return fmt.Errorf("failed to encode previous value: %w", err)
+		}
+		entry.PreviousValue = string(encoded)
+	}
+	if current != nil {
+		encoded, err := json.Marshal(current)
+		if err != nil {
+			return fmt.Errorf("failed to encode new value: %w", err)
+		}
+		entry.NewValue = string(encoded)
+	}
+
+	if err := repo.TaskHistory().Create(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record task history: %w", err)
+	}
+	return nil
+}
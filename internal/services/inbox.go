@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+)
+
+// NotificationPage is a single page of a user's notifications.
+type NotificationPage struct {
+	Items    []models.Notification `json:"items"`
+	Total    int64                 `json:"total"`
+	Page     int                   `json:"page"`
+	PageSize int                   `json:"page_size"`
+}
+
+// ListNotifications pages through the caller's notifications, newest first.
+// With unreadOnly, read notifications are left out.
+func (s *NotificationService) ListNotifications(ctx context.Context, actor Actor, unreadOnly bool, page, pageSize int) (*NotificationPage, error) {
+	page, pageSize = normalizePage(page, pageSize)
+
+	notifications, total, err := s.repo.Notifications().ListByUserID(ctx, actor.UserID, unreadOnly, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+
+	return &NotificationPage{
+		Items:    notifications,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+func (s *NotificationService) CountUnread(ctx context.Context, actor Actor) (int64, error) {
+	count, err := s.repo.Notifications().CountUnread(ctx, actor.UserID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkRead marks one of the caller's notifications read. Marking a read
+// notification again keeps its original read time.
+func (s *NotificationService) MarkRead(ctx context.Context, actor Actor, id uint) (*models.Notification, error) {
+	notification, err := s.repo.Notifications().GetByID(ctx, id)
+	if err != nil {
+		return nil, lookupError("notification", err)
+	}
+	// Other users' notifications are reported as missing.
+	if notification.UserID != actor.UserID {
+		return nil, fmt.Errorf("notification %w", ErrNotFound)
+	}
+	if notification.ReadAt != nil {
+		return notification, nil
+	}
+
+	now := time.Now()
+	if err := s.repo.Notifications().MarkRead(ctx, notification.ID, now); err != nil {
+		return nil, fmt.Errorf("failed to mark notification read: %w", err)
+	}
+	notification.ReadAt = &now
+	return notification, nil
+}
+
+// MarkAllRead marks every unread notification of the caller read and returns
+// how many there were.
+func (s *NotificationService) MarkAllRead(ctx context.Context, actor Actor) (int64, error) {
+	marked, err := s.repo.Notifications().MarkAllRead(ctx, actor.UserID, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return marked, nil
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	maxLabelNameLength = 50
+	defaultLabelColor  = "#808080"
+)
+
+var labelColorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+type LabelService struct {
+	repo repository.Repository
+}
+
+func NewLabelService(repo repository.Repository) *LabelService {
+	return &LabelService{repo: repo}
+}
+
+// LabelInput holds a label's name and color. An empty color keeps the current
+// one, or picks a neutral grey for a new label.
+type LabelInput struct {
+	Name  string
+	Color string
+}
+
+func (s *LabelService) ListLabels(ctx context.Context, actor Actor, workspaceID uint) ([]models.Label, error) {
+	if _, err := loadWorkspace(ctx, s.repo, actor, workspaceID, models.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+	return s.repo.Labels().ListByWorkspaceID(ctx, workspaceID)
+}
+
+func (s *LabelService) CreateLabel(ctx context.Context, actor Actor, workspaceID uint, input LabelInput) (*models.Label, error) {
+	if _, err := loadWorkspace(ctx, s.repo, actor, workspaceID, models.WorkspaceRoleManager); err != nil {
+		return nil, err
+	}
+
+	label := &models.Label{WorkspaceID: workspaceID, Color: defaultLabelColor}
+	if err := s.apply(ctx, label, input); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Labels().Create(ctx, label); err != nil {
+		return nil, fmt.Errorf("failed to create label: %w", err)
+	}
+	return label, nil
+}
+
+func (s *LabelService) UpdateLabel(ctx context.Context, actor Actor, id uint, input LabelInput) (*models.Label, error) {
+	label, err := s.loadLabel(ctx, actor, id, models.WorkspaceRoleManager)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.apply(ctx, label, input); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Labels().Update(ctx, label); err != nil {
+		return nil, fmt.Errorf("failed to update label: %w", err)
+	}
+	return label, nil
+}
+
+// DeleteLabel removes a label from the catalog and from every task carrying
+// it.
+func (s *LabelService) DeleteLabel(ctx context.Context, actor Actor, id uint) error {
+	label, err := s.loadLabel(ctx, actor, id, models.WorkspaceRoleManager)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if err := tx.Labels().Delete(ctx, label.ID); err != nil {
+			return fmt.Errorf("failed to delete label: %w", err)
+		}
+		return nil
+	})
+}
+
+// AttachLabel puts a label from the task's workspace on the task.
+func (s *LabelService) AttachLabel(ctx context.Context, actor Actor, taskID, labelID uint) (*models.Task, error) {
+	return s.changeTaskLabel(ctx, actor, taskID, labelID, true)
+}
+
+// DetachLabel takes a label off a task.
+func (s *LabelService) DetachLabel(ctx context.Context, actor Actor, taskID, labelID uint) (*models.Task, error) {
+	return s.changeTaskLabel(ctx, actor, taskID, labelID, false)
+}
+
+func (s *LabelService) changeTaskLabel(ctx context.Context, actor Actor, taskID, labelID uint, attach bool) (*models.Task, error) {
+	task, err := loadTask(ctx, s.repo, actor, taskID, models.WorkspaceRoleDev)
+	if err != nil {
+		return nil, err
+	}
+	label, err := s.repo.Labels().GetByID(ctx, labelID)
+	if err != nil {
+		return nil, lookupError("label", err)
+	}
+	if label.WorkspaceID != task.Project.WorkspaceID {
+		// Labels of other workspaces are reported as missing, like their tasks.
+		return nil, fmt.Errorf("label %w", ErrNotFound)
+	}
+
+	err = s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		var changed bool
+		var err error
+		if attach {
+			changed, err = tx.Labels().AddToTask(ctx, task.ID, label.ID)
+		} else {
+			changed, err = tx.Labels().RemoveFromTask(ctx, task.ID, label.ID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update task labels: %w", err)
+		}
+		if !changed {
+			return nil
+		}
+
+		value := map[string]interface{}{"label_id": label.ID, "name": label.Name}
+		if attach {
+			return recordTaskHistory(ctx, tx, task.ID, actor.UserID, models.HistoryChangeLabelAdd, nil, value)
+		}
+		return recordTaskHistory(ctx, tx, task.ID, actor.UserID, models.HistoryChangeLabelRemove, value, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	task, err = s.repo.Tasks().GetByID(ctx, task.ID)
+	if err != nil {
+		return nil, err
+	}
+	tasks := []models.Task{*task}
+	if err := annotateTasks(ctx, s.repo, tasks); err != nil {
+		return nil, err
+	}
+	return &tasks[0], nil
+}
+
+// loadLabel fetches a label the actor may access with at least minRole in its
+// workspace.
+func (s *LabelService) loadLabel(ctx context.Context, actor Actor, id uint, minRole models.WorkspaceRole) (*models.Label, error) {
+	label, err := s.repo.Labels().GetByID(ctx, id)
+	if err != nil {
+		return nil, lookupError("label", err)
+	}
+	if err := authorizeWorkspace(ctx, s.repo, actor, label.WorkspaceID, minRole, "label"); err != nil {
+		return nil, err
+	}
+	return label, nil
+}
+
+// apply validates input and copies it onto label. The name must not clash
+// with another label of the workspace, ignoring case.
+func (s *LabelService) apply(ctx context.Context, label *models.Label, input LabelInput) error {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return fmt.Errorf("%w: label name must not be empty", ErrInvalidInput)
+	}
+	if utf8.RuneCountInString(name) > maxLabelNameLength {
+		return fmt.Errorf("%w: label name must be at most %d characters", ErrInvalidInput, maxLabelNameLength)
+	}
+	if input.Color != "" {
+		color := strings.ToLower(strings.TrimSpace(input.Color))
+		if !labelColorPattern.MatchString(color) {
+			return fmt.Errorf("%w: color must be a hex color such as #d73a4a", ErrInvalidInput)
+		}
+		label.Color = color
+	}
+
+	existing, err := s.repo.Labels().GetByName(ctx, label.WorkspaceID, name)
+	if err == nil && existing.ID != label.ID {
+		return fmt.Errorf("%w: label %q already exists in this workspace", ErrConflict, existing.Name)
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("failed to load label: %w", err)
+	}
+	label.Name = name
+	return nil
+}
@@ -0,0 +1,407 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"strings"
+	texttemplate "text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/mail"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxNotificationDetail is how much of a comment is quoted in a
+	// notification, in characters.
+	maxNotificationDetail = 300
+	// mailTimeout bounds sending a single email.
+	mailTimeout = 30 * time.Second
+)
+
+//go:embed templates/*.tmpl
+var emailTemplates embed.FS
+
+var (
+	textEmails = texttemplate.Must(texttemplate.ParseFS(emailTemplates, "templates/email.txt.tmpl"))
+	htmlEmails = htmltemplate.Must(htmltemplate.ParseFS(emailTemplates, "templates/email.html.tmpl"))
+)
+
+// NotificationService tells users about changes to their tasks. A nil
+// NotificationService sends nothing.
+type NotificationService struct {
+	repo   repository.Repository
+	mailer mail.Mailer
+}
+
+func NewNotificationService(repo repository.Repository, mailer mail.Mailer) *NotificationService {
+	return &NotificationService{repo: repo, mailer: mailer}
+}
+
+// NotificationPreferencesInput holds the preferences to change; a nil
+// MutedKinds or EmailKinds or an empty EmailFrequency keeps the current value.
+type NotificationPreferencesInput struct {
+	MutedKinds     []models.NotificationKind
+	EmailKinds     []models.NotificationKind
+	EmailFrequency models.EmailFrequency
+}
+
+// notification is something that happened to a task which its recipients
+// should hear about.
+type notification struct {
+	Kind    models.NotificationKind
+	ActorID uint // Zero for changes made by the system
+	TaskID  uint
+	// From and To are the previous and new status of a status change.
+	From, To models.TaskStatus
+	Detail   string
+}
+
+func (s *NotificationService) GetPreferences(ctx context.Context, actor Actor) (*models.NotificationPreference, error) {
+	return s.preferences(ctx, actor.UserID)
+}
+
+func (s *NotificationService) UpdatePreferences(ctx context.Context, actor Actor, input NotificationPreferencesInput) (*models.NotificationPreference, error) {
+	preference, err := s.preferences(ctx, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.MutedKinds != nil {
+		if preference.MutedKinds, err = validateNotificationKinds("muted_kinds", input.MutedKinds); err != nil {
+			return nil, err
+		}
+	}
+	if input.EmailKinds != nil {
+		if preference.EmailKinds, err = validateNotificationKinds("email_kinds", input.EmailKinds); err != nil {
+			return nil, err
+		}
+	}
+	if input.EmailFrequency != "" {
+		if !validEmailFrequency(input.EmailFrequency) {
+			allowed := make([]string, 0, len(models.EmailFrequencies))
+			for _, f := range models.EmailFrequencies {
+				allowed = append(allowed, string(f))
+			}
+			return nil, &ValidationError{Field: "email_frequency", Value: string(input.EmailFrequency), Allowed: allowed}
+		}
+		preference.EmailFrequency = input.EmailFrequency
+	}
+
+	if err := s.repo.Notifications().SavePreference(ctx, preference); err != nil {
+		return nil, fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	return preference, nil
+}
+
+// preferences returns a user's notification preferences, falling back to the
+// defaults for users who never changed them.
+func (s *NotificationService) preferences(ctx context.Context, userID uint) (*models.NotificationPreference, error) {
+	preference, err := s.repo.Notifications().GetPreference(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.NotificationPreference{
+			UserID:         userID,
+			MutedKinds:     []models.NotificationKind{},
+			EmailKinds:     append([]models.NotificationKind(nil), models.DefaultEmailKinds...),
+			EmailFrequency: models.EmailInstant,
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notification preferences: %w", err)
+	}
+	if preference.MutedKinds == nil {
+		// Saved before kinds could be muted.
+		preference.MutedKinds = []models.NotificationKind{}
+	}
+	return preference, nil
+}
+
+// validateNotificationKinds checks that every kind is one of the notification
+// kinds and returns them without duplicates.
+func validateNotificationKinds(field string, kinds []models.NotificationKind) ([]models.NotificationKind, error) {
+	valid := make([]models.NotificationKind, 0, len(kinds))
+	for _, kind := range kinds {
+		if !kind.IsValid() {
+			allowed := make([]string, 0, len(models.NotificationKinds))
+			for _, k := range models.NotificationKinds {
+				allowed = append(allowed, string(k))
+			}
+			return nil, &ValidationError{Field: field, Value: string(kind), Allowed: allowed}
+		}
+		if !containsKind(valid, kind) {
+			valid = append(valid, kind)
+		}
+	}
+	return valid, nil
+}
+
+// notify tells each recipient except the actor about n, in their inbox unless
+// they muted its kind, and by email right away or in their next digest,
+// depending on their preferences. Recipients who are disabled or no longer
+// members of the task's workspace are skipped. It runs after the change was
+// committed (see committedContext).
+func (s *NotificationService) notify(ctx context.Context, n notification, recipients ...uint) {
+	if s == nil {
+		return
+	}
+	ctx = committedContext(ctx)
+
+	var task *models.Task
+	var summary string
+	for _, userID := range uniqueIDs(recipients) {
+		if userID == 0 || userID == n.ActorID {
+			continue
+		}
+		user, err := s.repo.Users().GetByID(ctx, userID)
+		if err != nil {
+			log.Printf("Notifications: failed to load user %d: %v", userID, err)
+			continue
+		}
+		if user.IsDisabled() {
+			continue
+		}
+		if task == nil {
+			if task, err = s.repo.Tasks().GetByID(ctx, n.TaskID); err != nil {
+				log.Printf("Notifications: failed to load task %d: %v", n.TaskID, err)
+				return
+			}
+			if summary, err = s.summarize(ctx, n); err != nil {
+				log.Printf("Notifications: %v", err)
+				return
+			}
+		}
+		// Users removed from the workspace no longer hear about its tasks.
+		if _, err := actorWorkspaceRole(ctx, s.repo, Actor{UserID: userID}, task.Project.WorkspaceID); err != nil {
+			continue
+		}
+		preference, err := s.preferences(ctx, userID)
+		if err != nil {
+			log.Printf("Notifications: %v", err)
+			continue
+		}
+		inbox := !containsKind(preference.MutedKinds, n.Kind)
+		email := preference.EmailFrequency != models.EmailOff && containsKind(preference.EmailKinds, n.Kind)
+		if !inbox && !email {
+			continue
+		}
+
+		if inbox {
+			entry := &models.Notification{
+				UserID:      userID,
+				Kind:        n.Kind,
+				TaskID:      task.ID,
+				TaskTitle:   task.Title,
+				ProjectName: task.Project.Name,
+				Summary:     summary,
+				Detail:      n.Detail,
+			}
+			if n.ActorID != 0 {
+				entry.ActorID = &n.ActorID
+			}
+			if err := s.repo.Notifications().Create(ctx, entry); err != nil {
+				log.Printf("Notifications: failed to add notification for user %d: %v", userID, err)
+			}
+		}
+		if !email {
+			continue
+		}
+
+		item := models.EmailDigestItem{
+			UserID:      userID,
+			Kind:        n.Kind,
+			TaskID:      task.ID,
+			TaskTitle:   task.Title,
+			ProjectName: task.Project.Name,
+			Summary:     summary,
+			Detail:      n.Detail,
+		}
+
+		if preference.EmailFrequency == models.EmailDaily {
+			if err := s.repo.Notifications().CreateDigestItem(ctx, &item); err != nil {
+				log.Printf("Notifications: failed to queue digest item for user %d: %v", userID, err)
+			}
+			continue
+		}
+		msg, err := renderEmail("notification", map[string]interface{}{"Username": user.Username, "Item": item})
+		if err != nil {
+			log.Printf("Notifications: %v", err)
+			return
+		}
+		msg.To = user.Email
+		msg.Subject = emailSubject(summary + ": " + task.Title)
+		go s.send(msg, userID)
+	}
+}
+
+// summarize describes n in a sentence, naming the user who caused it.
+func (s *NotificationService) summarize(ctx context.Context, n notification) (string, error) {
+	actor := "Someone"
+	if n.ActorID != 0 {
+		user, err := s.repo.Users().GetByID(ctx, n.ActorID)
+		if err != nil {
+			return "", fmt.Errorf("failed to load user %d: %w", n.ActorID, err)
+		}
+		actor = user.Username
+	}
+
+	switch n.Kind {
+	case models.NotificationAssigned:
+		return actor + " assigned a task to you", nil
+	case models.NotificationMentioned:
+		return actor + " mentioned you in a comment", nil
+	case models.NotificationStatusChanged:
+		return fmt.Sprintf("%s moved a task from %s to %s", actor, n.From, n.To), nil
+	case models.NotificationTaskUpdated:
+		return actor + " updated a task", nil
+	case models.NotificationOverdue:
+		return "A task assigned to you is overdue", nil
+	}
+	return "", fmt.Errorf("unknown notification kind %q", n.Kind)
+}
+
+// send emails msg in the background, logging failures.
+func (s *NotificationService) send(msg mail.Message, userID uint) {
+	ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+	defer cancel()
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		log.Printf("Notifications: failed to email user %d: %v", userID, err)
+	}
+}
+
+// StartEmailDigest launches a background goroutine that, every day at hour
+// (UTC), emails each user with waiting digest items a summary of them. Items
+// are only removed once their digest was sent, so failed ones are retried the
+// next day.
+func StartEmailDigest(repo repository.Repository, mailer mail.Mailer, hour int) {
+	go func() {
+		for {
+			time.Sleep(time.Until(nextDigestTime(time.Now(), hour)))
+			sent, err := sendEmailDigests(context.Background(), repo, mailer)
+			if err != nil {
+				log.Printf("Email digest: %v", err)
+			}
+			if sent > 0 {
+				log.Printf("Email digest: sent %d digests", sent)
+			}
+		}
+	}()
+}
+
+// nextDigestTime returns the first time after now that is hour:00 UTC.
+func nextDigestTime(now time.Time, hour int) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// sendEmailDigests emails every user with waiting digest items and returns how
+// many digests were sent. Items of disabled or deleted users are dropped.
+func sendEmailDigests(ctx context.Context, repo repository.Repository, mailer mail.Mailer) (int, error) {
+	userIDs, err := repo.Notifications().ListDigestRecipients(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list digest recipients: %w", err)
+	}
+
+	sent := 0
+	for _, userID := range userIDs {
+		items, err := repo.Notifications().ListDigestItems(ctx, userID)
+		if err != nil {
+			return sent, fmt.Errorf("failed to list digest items of user %d: %w", userID, err)
+		}
+		ids := make([]uint, len(items))
+		for i := range items {
+			ids[i] = items[i].ID
+		}
+
+		user, err := repo.Users().GetByID(ctx, userID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return sent, fmt.Errorf("failed to load user %d: %w", userID, err)
+		}
+		if err == nil && !user.IsDisabled() {
+			msg, err := renderEmail("digest", map[string]interface{}{"Username": user.Username, "Items": items})
+			if err != nil {
+				return sent, err
+			}
+			msg.To = user.Email
+			msg.Subject = fmt.Sprintf("Your daily task digest (%d %s)", len(items), plural(len(items), "update", "updates"))
+
+			sendCtx, cancel := context.WithTimeout(ctx, mailTimeout)
+			err = mailer.Send(sendCtx, msg)
+			cancel()
+			if err != nil {
+				log.Printf("Email digest: failed to email user %d: %v", userID, err)
+				continue
+			}
+			sent++
+		}
+
+		if err := repo.Notifications().DeleteDigestItems(ctx, ids); err != nil {
+			return sent, fmt.Errorf("failed to delete digest items of user %d: %w", userID, err)
+		}
+	}
+	return sent, nil
+}
+
+// renderEmail renders the named template of both email template sets.
+func renderEmail(name string, data interface{}) (mail.Message, error) {
+	var text, html bytes.Buffer
+	if err := textEmails.ExecuteTemplate(&text, name, data); err != nil {
+		return mail.Message{}, fmt.Errorf("failed to render %s email: %w", name, err)
+	}
+	if err := htmlEmails.ExecuteTemplate(&html, name, data); err != nil {
+		return mail.Message{}, fmt.Errorf("failed to render %s email: %w", name, err)
+	}
+	return mail.Message{Text: text.String(), HTML: html.String()}, nil
+}
+
+// emailSubject flattens s onto a single line and shortens it to a readable
+// length.
+func emailSubject(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	return truncate(s, 150)
+}
+
+// truncate shortens s to at most n characters, marking the cut with an
+// ellipsis.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+func containsKind(kinds []models.NotificationKind, kind models.NotificationKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func validEmailFrequency(frequency models.EmailFrequency) bool {
+	for _, f := range models.EmailFrequencies {
+		if f == frequency {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/mail"
+)
+
+// fakeDigestRepository adds waiting digest items to the users of
+// fakeUserRepository.
+type fakeDigestRepository struct {
+	*fakeUserRepository
+	items map[uint]models.EmailDigestItem
+}
+
+func (r *fakeDigestRepository) Notifications() repository.NotificationRepository {
+	return fakeNotifications{r: r}
+}
+
+type fakeNotifications struct {
+	repository.NotificationRepository
+	r *fakeDigestRepository
+}
+
+func (f fakeNotifications) ListDigestRecipients(ctx context.Context) ([]uint, error) {
+	seen := map[uint]bool{}
+	var userIDs []uint
+	for _, item := range f.r.items {
+		if !seen[item.UserID] {
+			seen[item.UserID] = true
+			userIDs = append(userIDs, item.UserID)
+		}
+	}
+	sort.Slice(userIDs, func(i, j int) bool { return userIDs[i] < userIDs[j] })
+	return userIDs, nil
+}
+
+func (f fakeNotifications) ListDigestItems(ctx context.Context, userID uint) ([]models.EmailDigestItem, error) {
+	var items []models.EmailDigestItem
+	for _, item := range f.r.items {
+		if item.UserID == userID {
+			items = append(items, item)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items, nil
+}
+
+func (f fakeNotifications) DeleteDigestItems(ctx context.Context, ids []uint) error {
+	for _, id := range ids {
+		delete(f.r.items, id)
+	}
+	return nil
+}
+
+// fakeMailer records the messages it sends and fails for the addresses in
+// failFor.
+type fakeMailer struct {
+	sent    []mail.Message
+	failFor map[string]bool
+}
+
+func (m *fakeMailer) Send(ctx context.Context, msg mail.Message) error {
+	if m.failFor[msg.To] {
+		return errors.New("connection refused")
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestNextDigestTime(t *testing.T) {
+	at := func(day, hour, minute, second, nsec int) time.Time {
+		return time.Date(2026, time.January, day, hour, minute, second, nsec, time.UTC)
+	}
+	berlin := time.FixedZone("CET", 60*60)
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{"just before the hour", at(10, 6, 59, 59, 999999999), at(10, 7, 0, 0, 0)},
+		{"on the hour", at(10, 7, 0, 0, 0), at(11, 7, 0, 0, 0)},
+		{"just after the hour", at(10, 7, 0, 0, 1), at(11, 7, 0, 0, 0)},
+		{"late evening", at(10, 23, 30, 0, 0), at(11, 7, 0, 0, 0)},
+		{"end of the month", at(31, 8, 0, 0, 0), time.Date(2026, time.February, 1, 7, 0, 0, 0, time.UTC)},
+		// 07:30 in Berlin is 06:30 UTC, before the digest hour.
+		{"other time zone", time.Date(2026, time.January, 10, 7, 30, 0, 0, berlin), at(10, 7, 0, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextDigestTime(tt.now, 7); !got.Equal(tt.want) {
+				t.Errorf("nextDigestTime(%v) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendEmailDigests(t *testing.T) {
+	disabledAt := time.Now()
+	repo := &fakeDigestRepository{
+		fakeUserRepository: &fakeUserRepository{users: map[uint]*models.User{
+			1: {ID: 1, Username: "ada", Email: "ada@example.com"},
+			2: {ID: 2, Username: "bob", Email: "bob@example.com"},
+			3: {ID: 3, Username: "cy", Email: "cy@example.com", DisabledAt: &disabledAt},
+			// User 4 no longer exists.
+		}},
+		items: map[uint]models.EmailDigestItem{
+			1: {ID: 1, UserID: 1, Kind: models.NotificationAssigned, TaskTitle: "Write launch plan", ProjectName: "Apollo", Summary: "You were assigned"},
+			2: {ID: 2, UserID: 1, Kind: models.NotificationMentioned, TaskTitle: "Book venue", ProjectName: "Apollo", Summary: "You were mentioned"},
+			3: {ID: 3, UserID: 2, Kind: models.NotificationOverdue, TaskTitle: "File taxes", ProjectName: "Admin", Summary: "Overdue"},
+			4: {ID: 4, UserID: 3, Kind: models.NotificationOverdue, TaskTitle: "Old task", ProjectName: "Admin", Summary: "Overdue"},
+			5: {ID: 5, UserID: 4, Kind: models.NotificationOverdue, TaskTitle: "Orphan", ProjectName: "Admin", Summary: "Overdue"},
+		},
+	}
+	mailer := &fakeMailer{failFor: map[string]bool{"bob@example.com": true}}
+
+	sent, err := sendEmailDigests(context.Background(), repo, mailer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 1 || len(mailer.sent) != 1 {
+		t.Fatalf("sent %d digests, mailer got %d, want 1 and 1", sent, len(mailer.sent))
+	}
+
+	msg := mailer.sent[0]
+	if msg.To != "ada@example.com" || msg.Subject != "Your daily task digest (2 updates)" {
+		t.Errorf("sent %q to %s", msg.Subject, msg.To)
+	}
+	for _, title := range []string{"Write launch plan", "Book venue"} {
+		if !strings.Contains(msg.Text, title) || !strings.Contains(msg.HTML, title) {
+			t.Errorf("digest does not mention %q", title)
+		}
+	}
+
+	// Only the failed digest is kept for the next day.
+	var kept []uint
+	for id := range repo.items {
+		kept = append(kept, id)
+	}
+	if len(kept) != 1 || kept[0] != 3 {
+		t.Errorf("kept items %v, want [3]", kept)
+	}
+
+	mailer.failFor = nil
+	if sent, err := sendEmailDigests(context.Background(), repo, mailer); err != nil || sent != 1 {
+		t.Fatalf("retry sent %d (%v), want 1", sent, err)
+	}
+	if got := mailer.sent[1]; got.To != "bob@example.com" || got.Subject != "Your daily task digest (1 update)" {
+		t.Errorf("retry sent %q to %s", got.Subject, got.To)
+	}
+	if len(repo.items) != 0 {
+		t.Errorf("%d items left after the retry", len(repo.items))
+	}
+}
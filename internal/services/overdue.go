@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/events"
+)
+
+// StartOverdueMonitor launches a background goroutine that periodically flags
+// tasks which have passed their due date without being completed. Each task is
+// flagged once, with an OVERDUE entry in its history, a task.updated event on
+// hub and to webhooks, and a notification to its assignee; errors are logged
+// and retried on the next tick.
+func StartOverdueMonitor(repo repository.Repository, hub *events.Hub, notifications *NotificationService, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			<-ticker.C
+			flagged, err := flagOverdueTasks(context.Background(), repo, hub, notifications, time.Now())
+			if err != nil {
+				log.Printf("Overdue monitor: %v", err)
+			}
+			if flagged > 0 {
+				log.Printf("Overdue monitor: flagged %d overdue tasks", flagged)
+			}
+		}
+	}()
+}
+
+// flagOverdueTasks flags every task that became overdue by now and returns how
+// many were flagged. Tasks flagged concurrently by another instance are
+// skipped, so each one gets a single history entry.
+func flagOverdueTasks(ctx context.Context, repo repository.Repository, hub *events.Hub, notifications *NotificationService, now time.Time) (int, error) {
+	tasks, err := repo.Tasks().ListNewlyOverdue(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list overdue tasks: %w", err)
+	}
+
+	flagged := 0
+	for _, task := range tasks {
+		var marked bool
+		err := repo.Transaction(ctx, func(tx repository.Repository) error {
+			var err error
+			if marked, err = tx.Tasks().MarkOverdue(ctx, task.ID, now); err != nil {
+				return fmt.Errorf("failed to flag task %d: %w", task.ID, err)
+			}
+			if !marked {
+				return nil
+			}
+			current := map[string]interface{}{"due_date": formatTime(task.DueDate)}
+			return recordTaskHistory(ctx, tx, task.ID, 0, models.HistoryChangeOverdue, nil, current)
+		})
+		if err != nil {
+			return flagged, err
+		}
+		if marked {
+			flagged++
+			publishOverdueTask(ctx, repo, hub, task.ID)
+			if task.AssigneeID != nil {
+				notifications.notify(ctx, notification{
+					Kind:   models.NotificationOverdue,
+					TaskID: task.ID,
+					Detail: "Due " + task.DueDate.UTC().Format("Mon, 2 Jan 2006 15:04 MST"),
+				}, *task.AssigneeID)
+			}
+		}
+	}
+	return flagged, nil
+}
+
+// publishOverdueTask publishes a task.updated event for a task that was just
+// flagged, with the task as the API returns it. Failures are logged; the flag
+// itself is already committed.
+func publishOverdueTask(ctx context.Context, repo repository.Repository, hub *events.Hub, taskID uint) {
+	task, err := repo.Tasks().GetByID(ctx, taskID)
+	if err != nil {
+		log.Printf("Overdue monitor: failed to load task %d: %v", taskID, err)
+		return
+	}
+	tasks := []models.Task{*task}
+	if err := annotateTasks(ctx, repo, tasks); err != nil {
+		log.Printf("Overdue monitor: %v", err)
+		return
+	}
+	publishTaskEvent(ctx, repo, hub, models.EventTaskUpdated, task.Project.WorkspaceID, &tasks[0])
+}
@@ -16,7 +16,11 @@ func NewProjectService(repo repository.Repository) *ProjectService {
 	return &ProjectService{repo: repo}
 }
 
-func (s *ProjectService) CreateProject(ctx context.Context, name string, workspaceID uint) (*models.Project, error) {
+func (s *ProjectService) CreateProject(ctx context.Context, actor Actor, name string, workspaceID uint) (*models.Project, error) {
+	if _, err := loadWorkspace(ctx, s.repo, actor, workspaceID, models.WorkspaceRoleManager); err != nil {
+		return nil, err
+	}
+
 	project := &models.Project{
 		Name:        name,
 		WorkspaceID: workspaceID,
@@ -26,13 +30,106 @@ func (s *ProjectService) CreateProject(ctx context.Context, name string, workspa
 		return nil, fmt.Errorf("failed to create project: %w", err)
 	}
 
+	enqueueWebhooks(ctx, s.repo, project.WorkspaceID, models.EventProjectCreated, project)
 	return project, nil
 }
 
-func (s *ProjectService) GetProject(ctx context.Context, id uint) (*models.Project, error) {
-	return s.repo.Projects().GetByID(ctx, id)
+func (s *ProjectService) GetProject(ctx context.Context, actor Actor, id uint) (*models.Project, error) {
+	return loadProject(ctx, s.repo, actor, id, models.WorkspaceRoleViewer)
 }
 
-func (s *ProjectService) ListWorkspaceProjects(ctx context.Context, workspaceID uint) ([]models.Project, error) {
+func (s *ProjectService) ListWorkspaceProjects(ctx context.Context, actor Actor, workspaceID uint) ([]models.Project, error) {
+	if _, err := loadWorkspace(ctx, s.repo, actor, workspaceID, models.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
 	return s.repo.Projects().ListByWorkspaceID(ctx, workspaceID)
 }
+
+// UpdateProjectInput holds a project's new settings. EnforceDependencies is
+// left alone when nil.
+type UpdateProjectInput struct {
+	Name                string
+	EnforceDependencies *bool
+}
+
+func (s *ProjectService) UpdateProject(ctx context.Context, actor Actor, id uint, input UpdateProjectInput) (*models.Project, error) {
+	project, err := loadProject(ctx, s.repo, actor, id, models.WorkspaceRoleManager)
+	if err != nil {
+		return nil, err
+	}
+
+	project.Name = input.Name
+	if input.EnforceDependencies != nil {
+		project.EnforceDependencies = *input.EnforceDependencies
+	}
+	if err := s.repo.Projects().Update(ctx, project); err != nil {
+		return nil, fmt.Errorf("failed to update project: %w", err)
+	}
+
+	enqueueWebhooks(ctx, s.repo, project.WorkspaceID, models.EventProjectUpdated, project)
+	return project, nil
+}
+
+// ArchiveProject soft-deletes the project together with its tasks.
+func (s *ProjectService) ArchiveProject(ctx context.Context, actor Actor, id uint) error {
+	project, err := loadProject(ctx, s.repo, actor, id, models.WorkspaceRoleManager)
+	if err != nil {
+		return err
+	}
+
+	at := archiveTimestamp()
+	err = s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if err := tx.Tasks().ArchiveByProjectID(ctx, id, at); err != nil {
+			return fmt.Errorf("failed to archive project tasks: %w", err)
+		}
+		if err := tx.Projects().Archive(ctx, id, at); err != nil {
+			return fmt.Errorf("failed to archive project: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	enqueueWebhooks(ctx, s.repo, project.WorkspaceID, models.EventProjectArchived, project)
+	return nil
+}
+
+// RestoreProject brings back an archived project and the tasks archived with
+// it. A project cannot be restored while its workspace is archived.
+func (s *ProjectService) RestoreProject(ctx context.Context, actor Actor, id uint) (*models.Project, error) {
+	project, err := s.repo.Projects().GetIncludingArchived(ctx, id)
+	if err != nil {
+		return nil, lookupError("project", err)
+	}
+	if err := authorizeWorkspace(ctx, s.repo, actor, project.WorkspaceID, models.WorkspaceRoleManager, "project"); err != nil {
+		return nil, err
+	}
+	if !project.DeletedAt.Valid {
+		return nil, fmt.Errorf("%w: project is not archived", ErrConflict)
+	}
+	if project.Workspace.DeletedAt.Valid {
+		return nil, fmt.Errorf("%w: restore the workspace first", ErrConflict)
+	}
+
+	at := project.DeletedAt.Time
+	err = s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if err := tx.Projects().Restore(ctx, id); err != nil {
+			return fmt.Errorf("failed to restore project: %w", err)
+		}
+		if err := tx.Tasks().RestoreByProjectID(ctx, id, at); err != nil {
+			return fmt.Errorf("failed to restore project tasks: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	project, err = s.repo.Projects().GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	enqueueWebhooks(ctx, s.repo, project.WorkspaceID, models.EventProjectRestored, project)
+	return project, nil
+}
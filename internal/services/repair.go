@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+)
+
+// TaskRepair describes the fix for one invalid field of a task.
+type TaskRepair struct {
+	TaskID uint
+	Field  string
+	From   string
+	To     string
+}
+
+// RepairInvalidTasks finds tasks, archived ones included, whose priority is not
+// a valid priority or whose status is not part of their project's workflow.
+// Each bad value is replaced by the valid one it matches case-insensitively,
+// or otherwise by MEDIUM or the workflow's initial status. The fixes are only
+// written when apply is set, each with an UPDATE history entry; either way
+// they are returned.
+func (s *TaskService) RepairInvalidTasks(ctx context.Context, apply bool) ([]TaskRepair, error) {
+	defaults := models.DefaultWorkflow(0)
+	tasks, err := s.repo.Tasks().ListInvalid(ctx, models.TaskPriorities, defaults.StatusNames())
+	if err != nil {
+		return nil, fmt.Errorf("failed to find invalid tasks: %w", err)
+	}
+
+	workflows := map[uint]*models.Workflow{}
+	var repairs []TaskRepair
+	for _, task := range tasks {
+		workflow, ok := workflows[task.ProjectID]
+		if !ok {
+			if workflow, err = projectWorkflow(ctx, s.repo, task.ProjectID); err != nil {
+				return nil, err
+			}
+			workflows[task.ProjectID] = workflow
+		}
+
+		status, priority := task.Status, task.Priority
+		if !workflow.HasStatus(status) {
+			status = workflow.InitialStatus()
+			for _, name := range workflow.StatusNames() {
+				if strings.EqualFold(string(name), string(task.Status)) {
+					status = name
+				}
+			}
+			repairs = append(repairs, TaskRepair{TaskID: task.ID, Field: "status", From: string(task.Status), To: string(status)})
+		}
+		if !priority.IsValid() {
+			priority = models.TaskPriorityMedium
+			for _, p := range models.TaskPriorities {
+				if strings.EqualFold(string(p), string(task.Priority)) {
+					priority = p
+				}
+			}
+			repairs = append(repairs, TaskRepair{TaskID: task.ID, Field: "priority", From: string(task.Priority), To: string(priority)})
+		}
+
+		if apply {
+			previous, current := diffFields(
+				map[string]interface{}{"status": task.Status, "priority": task.Priority},
+				map[string]interface{}{"status": status, "priority": priority},
+			)
+			err := s.repo.Transaction(ctx, func(tx repository.Repository) error {
+				if err := tx.Tasks().Repair(ctx, task.ID, status, priority); err != nil {
+					return fmt.Errorf("failed to repair task %d: %w", task.ID, err)
+				}
+				// Recorded as a system change, with no user.
+				return recordTaskHistory(ctx, tx, task.ID, 0, models.HistoryChangeUpdate, previous, current)
+			})
+			if err != nil {
+				return repairs, err
+			}
+		}
+	}
+
+	return repairs, nil
+}
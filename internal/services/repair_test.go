@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+)
+
+// fakeRepairRepository holds tasks with invalid values, the custom workflows
+// of their projects and the history written while repairing them.
+type fakeRepairRepository struct {
+	repository.Repository
+	tasks     map[uint]*models.Task
+	workflows map[uint]*models.Workflow
+	history   []models.TaskHistory
+	writes    int
+}
+
+func (r *fakeRepairRepository) Transaction(ctx context.Context, fn func(tx repository.Repository) error) error {
+	return fn(r)
+}
+
+func (r *fakeRepairRepository) Tasks() repository.TaskRepository {
+	return fakeRepairTasks{r: r}
+}
+
+func (r *fakeRepairRepository) Workflows() repository.WorkflowRepository {
+	return fakeWorkflows{r: r}
+}
+
+func (r *fakeRepairRepository) TaskHistory() repository.TaskHistoryRepository {
+	return fakeTaskHistory{r: r}
+}
+
+type fakeRepairTasks struct {
+	repository.TaskRepository
+	r *fakeRepairRepository
+}
+
+// ListInvalid returns every task of the fixture, which only holds invalid
+// ones, in ID order.
+func (f fakeRepairTasks) ListInvalid(ctx context.Context, priorities []models.TaskPriority, defaultStatuses []models.TaskStatus) ([]models.Task, error) {
+	var tasks []models.Task
+	for _, task := range f.r.tasks {
+		tasks = append(tasks, *task)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	return tasks, nil
+}
+
+func (f fakeRepairTasks) Repair(ctx context.Context, id uint, status models.TaskStatus, priority models.TaskPriority) error {
+	f.r.writes++
+	f.r.tasks[id].Status = status
+	f.r.tasks[id].Priority = priority
+	return nil
+}
+
+type fakeWorkflows struct {
+	repository.WorkflowRepository
+	r *fakeRepairRepository
+}
+
+func (f fakeWorkflows) Get(ctx context.Context, projectID uint) (*models.Workflow, error) {
+	return f.r.workflows[projectID], nil
+}
+
+type fakeTaskHistory struct {
+	repository.TaskHistoryRepository
+	r *fakeRepairRepository
+}
+
+func (f fakeTaskHistory) Create(ctx context.Context, history *models.TaskHistory) error {
+	f.r.writes++
+	f.r.history = append(f.r.history, *history)
+	return nil
+}
+
+// releaseWorkflow is a custom workflow of BACKLOG (initial), REVIEW and
+// SHIPPED (done).
+func releaseWorkflow(projectID uint) *models.Workflow {
+	return &models.Workflow{
+		ProjectID: projectID,
+		Custom:    true,
+		Statuses: []models.WorkflowStatus{
+			{ProjectID: projectID, Name: "BACKLOG", Position: 0, Initial: true},
+			{ProjectID: projectID, Name: "REVIEW", Position: 1},
+			{ProjectID: projectID, Name: "SHIPPED", Position: 2, Done: true},
+		},
+	}
+}
+
+// newRepairFixture returns tasks with broken values in project 1, which uses
+// the default workflow, and project 2, which uses releaseWorkflow.
+func newRepairFixture() *fakeRepairRepository {
+	return &fakeRepairRepository{
+		tasks: map[uint]*models.Task{
+			1: {ID: 1, ProjectID: 1, Status: "done", Priority: "high"},
+			2: {ID: 2, ProjectID: 1, Status: "In_Progress", Priority: models.TaskPriorityLow},
+			3: {ID: 3, ProjectID: 1, Status: "BLOCKED", Priority: "urgent"},
+			4: {ID: 4, ProjectID: 1, Status: models.TaskStatusTodo, Priority: ""},
+			5: {ID: 5, ProjectID: 2, Status: "review", Priority: models.TaskPriorityHigh},
+			6: {ID: 6, ProjectID: 2, Status: models.TaskStatusDone, Priority: "Medium"},
+		},
+		workflows: map[uint]*models.Workflow{2: releaseWorkflow(2)},
+	}
+}
+
+var wantRepairs = []TaskRepair{
+	{TaskID: 1, Field: "status", From: "done", To: "DONE"},
+	{TaskID: 1, Field: "priority", From: "high", To: "HIGH"},
+	{TaskID: 2, Field: "status", From: "In_Progress", To: "IN_PROGRESS"},
+	{TaskID: 3, Field: "status", From: "BLOCKED", To: "TODO"},
+	{TaskID: 3, Field: "priority", From: "urgent", To: "MEDIUM"},
+	{TaskID: 4, Field: "priority", From: "", To: "MEDIUM"},
+	{TaskID: 5, Field: "status", From: "review", To: "REVIEW"},
+	// DONE is not part of the custom workflow, so the task starts over.
+	{TaskID: 6, Field: "status", From: "DONE", To: "BACKLOG"},
+	{TaskID: 6, Field: "priority", From: "Medium", To: "MEDIUM"},
+}
+
+func TestRepairInvalidTasksDryRun(t *testing.T) {
+	repo := newRepairFixture()
+
+	repairs, err := NewTaskService(repo, nil, nil).RepairInvalidTasks(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(repairs, wantRepairs) {
+		t.Errorf("repairs\n%+v\nwant\n%+v", repairs, wantRepairs)
+	}
+	if repo.writes != 0 {
+		t.Errorf("dry run made %d writes", repo.writes)
+	}
+	if repo.tasks[1].Status != "done" {
+		t.Errorf("dry run changed task 1 to %s", repo.tasks[1].Status)
+	}
+}
+
+func TestRepairInvalidTasksApply(t *testing.T) {
+	repo := newRepairFixture()
+
+	repairs, err := NewTaskService(repo, nil, nil).RepairInvalidTasks(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(repairs, wantRepairs) {
+		t.Errorf("repairs\n%+v\nwant\n%+v", repairs, wantRepairs)
+	}
+
+	want := map[uint]struct {
+		status   models.TaskStatus
+		priority models.TaskPriority
+	}{
+		1: {"DONE", "HIGH"},
+		2: {"IN_PROGRESS", "LOW"},
+		3: {"TODO", "MEDIUM"},
+		4: {"TODO", "MEDIUM"},
+		5: {"REVIEW", "HIGH"},
+		6: {"BACKLOG", "MEDIUM"},
+	}
+	for id, w := range want {
+		if task := repo.tasks[id]; task.Status != w.status || task.Priority != w.priority {
+			t.Errorf("task %d is %s/%s, want %s/%s", id, task.Status, task.Priority, w.status, w.priority)
+		}
+	}
+
+	if len(repo.history) != len(want) {
+		t.Fatalf("%d history entries, want one per task", len(repo.history))
+	}
+	entry := repo.history[0]
+	if entry.TaskID != 1 || entry.ChangeType != models.HistoryChangeUpdate || entry.UserID != nil {
+		t.Errorf("history entry %+v, want a system UPDATE of task 1", entry)
+	}
+	var previous, current map[string]string
+	if err := json.Unmarshal([]byte(entry.PreviousValue), &previous); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(entry.NewValue), &current); err != nil {
+		t.Fatal(err)
+	}
+	if previous["status"] != "done" || previous["priority"] != "high" || current["status"] != "DONE" || current["priority"] != "HIGH" {
+		t.Errorf("history recorded %v -> %v", previous, current)
+	}
+	// Only the fields that changed are recorded.
+	var statusOnly map[string]string
+	if err := json.Unmarshal([]byte(repo.history[1].NewValue), &statusOnly); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := statusOnly["priority"]; ok || statusOnly["status"] != "IN_PROGRESS" {
+		t.Errorf("history of task 2 recorded %v, want only the status", statusOnly)
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+)
+
+const maxSearchQueryLength = 200
+
+type SearchService struct {
+	repo repository.Repository
+}
+
+func NewSearchService(repo repository.Repository) *SearchService {
+	return &SearchService{repo: repo}
+}
+
+// SearchQuery holds the user-supplied search text and filters. Types holds
+// result types (task, comment, project); empty searches them all.
+type SearchQuery struct {
+	Query       string
+	Types       []string
+	WorkspaceID uint
+	Page        int
+	PageSize    int
+}
+
+// SearchResult is a task, comment or project matching a search. Title is the
+// task title, or the project name for projects. Snippet is HTML-escaped text
+// around the matches, with each match wrapped in <mark> tags.
+type SearchResult struct {
+	Type        string  `json:"type"`
+	ID          uint    `json:"id"`
+	TaskID      *uint   `json:"task_id,omitempty"`
+	ProjectID   uint    `json:"project_id"`
+	WorkspaceID uint    `json:"workspace_id"`
+	Title       string  `json:"title"`
+	Snippet     string  `json:"snippet"`
+	Rank        float64 `json:"rank"`
+}
+
+// SearchPage is a single page of search results, best match first.
+type SearchPage struct {
+	Items    []SearchResult `json:"items"`
+	Total    int64          `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+}
+
+var searchResultTypes = []string{
+	string(repository.SearchResultTask),
+	string(repository.SearchResultComment),
+	string(repository.SearchResultProject),
+}
+
+// Search finds live tasks, comments and projects matching query.Query in the
+// workspaces the actor belongs to; admins search every workspace.
+func (s *SearchService) Search(ctx context.Context, actor Actor, query SearchQuery) (*SearchPage, error) {
+	text := strings.TrimSpace(query.Query)
+	if text == "" {
+		return nil, fmt.Errorf("%w: search query must not be empty", ErrInvalidInput)
+	}
+	if utf8.RuneCountInString(text) > maxSearchQueryLength {
+		return nil, fmt.Errorf("%w: search query must be at most %d characters", ErrInvalidInput, maxSearchQueryLength)
+	}
+
+	filter := repository.SearchFilter{Query: text}
+	for _, t := range query.Types {
+		if !containsString(searchResultTypes, t) {
+			return nil, &ValidationError{Field: "type", Value: t, Allowed: searchResultTypes}
+		}
+		filter.Types = append(filter.Types, repository.SearchResultType(t))
+	}
+	if query.WorkspaceID != 0 {
+		if _, err := loadWorkspace(ctx, s.repo, actor, query.WorkspaceID, models.WorkspaceRoleViewer); err != nil {
+			return nil, err
+		}
+		filter.WorkspaceID = query.WorkspaceID
+	}
+	if !actor.IsAdmin() {
+		filter.MemberID = actor.UserID
+	}
+
+	page, pageSize := normalizePage(query.Page, query.PageSize)
+	filter.Limit = pageSize
+	filter.Offset = (page - 1) * pageSize
+
+	hits, total, err := s.repo.Search().Search(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	results := make([]SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		results = append(results, SearchResult{
+			Type:        string(hit.Type),
+			ID:          hit.ID,
+			TaskID:      hit.TaskID,
+			ProjectID:   hit.ProjectID,
+			WorkspaceID: hit.WorkspaceID,
+			Title:       hit.Title,
+			Snippet:     hit.Snippet,
+			Rank:        hit.Rank,
+		})
+	}
+	return &SearchPage{
+		Items:    results,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+	"gorm.io/gorm"
+)
+
+// ListSubtasks pages through the direct subtasks of a task.
+func (s *TaskService) ListSubtasks(ctx context.Context, actor Actor, id uint, query TaskQuery) (*TaskPage, error) {
+	task, err := loadTask(ctx, s.repo, actor, id, models.WorkspaceRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateQueryStatuses(ctx, s.repo, task.ProjectID, query.Statuses); err != nil {
+		return nil, err
+	}
+	query.TopLevel = false
+	return s.listTasks(ctx, repository.TaskFilter{ProjectID: task.ProjectID, ParentID: task.ID}, query)
+}
+
+// MoveTask makes a task a subtask of parentID, or a top-level task when
+// parentID is nil. The parent must be in the same project and must not be the
+// task itself or one of its subtasks.
+func (s *TaskService) MoveTask(ctx context.Context, actor Actor, id uint, parentID *uint) (*models.Task, error) {
+	task, err := loadTask(ctx, s.repo, actor, id, models.WorkspaceRoleDev)
+	if err != nil {
+		return nil, err
+	}
+	if parentID != nil {
+		if err := checkParent(ctx, s.repo, task.ID, task.ProjectID, *parentID, task.CompletedAt != nil); err != nil {
+			return nil, err
+		}
+	}
+	before := taskFields(task)
+
+	task.ParentID = parentID
+
+	previous, current := diffFields(before, taskFields(task))
+	if len(current) == 0 {
+		return s.annotateTask(ctx, task)
+	}
+
+	err = s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if err := tx.Tasks().Update(ctx, task); err != nil {
+			return fmt.Errorf("failed to move task: %w", err)
+		}
+		return recordTaskHistory(ctx, tx, task.ID, actor.UserID, models.HistoryChangeUpdate, previous, current)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	task, err = s.repo.Tasks().GetByID(ctx, task.ID)
+	if err != nil {
+		return nil, err
+	}
+	task, err = s.annotateTask(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	s.publishTask(ctx, models.EventTaskUpdated, task.Project.WorkspaceID, task)
+	s.notifyTaskChange(ctx, actor, task, task.AssigneeID, task.Status, current)
+	return task, nil
+}
+
+// checkParent verifies that parentID can become the parent of a task in
+// projectID; taskID is 0 for a task being created. An open task cannot be put
+// under a completed parent.
+func checkParent(ctx context.Context, repo repository.Repository, taskID, projectID, parentID uint, completed bool) error {
+	parent, err := repo.Tasks().GetByID(ctx, parentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: parent task %d does not exist", ErrInvalidInput, parentID)
+		}
+		return fmt.Errorf("failed to load parent task: %w", err)
+	}
+	if parent.ProjectID != projectID {
+		return fmt.Errorf("%w: parent task must belong to the same project", ErrInvalidInput)
+	}
+	if taskID != 0 {
+		cycle, err := repo.Tasks().IsAncestor(ctx, taskID, parent.ID)
+		if err != nil {
+			return fmt.Errorf("failed to check task hierarchy: %w", err)
+		}
+		if cycle {
+			return fmt.Errorf("%w: a task cannot be moved under itself or one of its subtasks", ErrUnprocessable)
+		}
+	}
+	if parent.CompletedAt != nil && !completed {
+		return fmt.Errorf("%w: parent task is already completed", ErrUnprocessable)
+	}
+	return nil
+}
+
+// requireSubtasksDone fails when a task still has open subtasks, at any depth.
+func requireSubtasksDone(ctx context.Context, repo repository.Repository, taskID uint) error {
+	counts, err := repo.Tasks().Progress(ctx, []uint{taskID})
+	if err != nil {
+		return fmt.Errorf("failed to load subtasks: %w", err)
+	}
+	for _, count := range counts {
+		if open := count.Total - count.Completed; open > 0 {
+			return fmt.Errorf("%w: task has %d open subtasks", ErrUnprocessable, open)
+		}
+	}
+	return nil
+}
+
+// attachProgress fills in the subtask roll-up of every task that has
+// subtasks.
+func attachProgress(ctx context.Context, repo repository.Repository, tasks []models.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	counts, err := repo.Tasks().Progress(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to load subtask progress: %w", err)
+	}
+
+	byTask := make(map[uint]repository.TaskProgressCount, len(counts))
+	for _, count := range counts {
+		byTask[count.TaskID] = count
+	}
+	for i := range tasks {
+		count, ok := byTask[tasks[i].ID]
+		if !ok || count.Total == 0 {
+			continue
+		}
+		tasks[i].Progress = &models.TaskProgress{
+			Total:     count.Total,
+			Completed: count.Completed,
+			Percent:   int(count.Completed * 100 / count.Total),
+		}
+	}
+	return nil
+}
@@ -3,17 +3,26 @@ package services
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/events"
 )
 
 type TaskService struct {
-	repo repository.Repository
+	repo          repository.Repository
+	events        *events.Hub
+	notifications *NotificationService
 }
 
-func NewTaskService(repo repository.Repository) *TaskService {
-	return &TaskService{repo: repo}
+// NewTaskService returns a TaskService that publishes task changes to hub and
+// tells assignees about them through notifications; either may be nil.
+func NewTaskService(repo repository.Repository, hub *events.Hub, notifications *NotificationService) *TaskService {
+	return &TaskService{repo: repo, events: hub, notifications: notifications}
 }
 
 type CreateTaskInput struct {
@@ -23,23 +32,283 @@ type CreateTaskInput struct {
 	Priority    models.TaskPriority
 	AssigneeID  *uint
 	ProjectID   uint
+	ParentID    *uint
+	StartDate   *time.Time
+	DueDate     *time.Time
+	// CustomFields holds values keyed by custom field key, as decoded from
+	// JSON.
+	CustomFields map[string]interface{}
 }
 
+// UpdateTaskInput holds the fields to change; nil fields are left alone.
+// ClearStartDate and ClearDueDate remove the corresponding date.
 type UpdateTaskInput struct {
-	Title       *string
-	Description *string
-	Status      *models.TaskStatus
-	Priority    *models.TaskPriority
-	AssigneeID  *uint
+	Title          *string
+	Description    *string
+	Status         *models.TaskStatus
+	Priority       *models.TaskPriority
+	AssigneeID     *uint
+	StartDate      *time.Time
+	DueDate        *time.Time
+	ClearStartDate bool
+	ClearDueDate   bool
+	// CustomFields holds the custom field values to set, keyed by field key;
+	// a nil value clears the field.
+	CustomFields map[string]interface{}
+}
+
+// TaskQuery holds the user-supplied filters, ordering and cursor for a task
+// listing.
+type TaskQuery struct {
+	Statuses   []models.TaskStatus
+	Priorities []models.TaskPriority
+	AssigneeID uint
+	Unassigned bool
+	Search     string
+	TopLevel   bool
+	// LabelIDs keeps tasks with any of the labels, or with all of them when
+	// AllLabels is set.
+	LabelIDs      []uint
+	AllLabels     bool
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	DueAfter      *time.Time
+	DueBefore     *time.Time
+	// Due selects overdue tasks or tasks due today or this week (starting
+	// Monday) in Location. It takes precedence over DueAfter and DueBefore.
+	Due      string
+	Location *time.Location
+	// CustomFields keeps tasks whose value for each custom field key is one
+	// of the listed values. Only usable within a single project.
+	CustomFields map[string][]string
+	// Sort is a column name, or cf.<key> to order by a custom field.
+	Sort     string
+	Desc     bool
+	Cursor   string
+	PageSize int
+}
+
+// TaskPage is a single page of a task listing. NextCursor is empty on the last
+// page.
+type TaskPage struct {
+	Items      []models.Task `json:"items"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+	HasMore    bool          `json:"has_more"`
+	PageSize   int           `json:"page_size"`
+}
+
+// TaskGroup is the number of tasks matching a listing in one project.
+type TaskGroup struct {
+	ProjectID   uint   `json:"project_id"`
+	ProjectName string `json:"project_name"`
+	WorkspaceID uint   `json:"workspace_id"`
+	Count       int64  `json:"count"`
+}
+
+// MyTasksPage is a page of the caller's assigned tasks, optionally with the
+// per-project totals of everything matching the filters.
+type MyTasksPage struct {
+	TaskPage
+	Groups []TaskGroup `json:"groups,omitempty"`
+}
+
+// taskPriorityRank is used when ordering by priority; it must match the
+// ranking the task repository sorts by.
+var taskPriorityRank = map[models.TaskPriority]int{
+	models.TaskPriorityLow:    1,
+	models.TaskPriorityMedium: 2,
+	models.TaskPriorityHigh:   3,
 }
 
-func (s *TaskService) CreateTask(ctx context.Context, input CreateTaskInput) (*models.Task, error) {
+// taskSortValues renders a task's value for each sort column as it is stored
+// in a cursor. Status is missing since its rank depends on the project's
+// workflow; see statusSortValue.
+var taskSortValues = map[repository.TaskSort]func(task *models.Task) string{
+	repository.TaskSortCreatedAt: func(task *models.Task) string { return task.CreatedAt.Format(time.RFC3339Nano) },
+	repository.TaskSortUpdatedAt: func(task *models.Task) string { return task.UpdatedAt.Format(time.RFC3339Nano) },
+	repository.TaskSortTitle:     func(task *models.Task) string { return task.Title },
+	repository.TaskSortPriority:  func(task *models.Task) string { return strconv.Itoa(taskPriorityRank[task.Priority]) },
+}
+
+// statusSortValue renders a task's status rank for a cursor the way the task
+// repository sorts by it: the status's position in the project's workflow,
+// else in the default workflow, else -1.
+func statusSortValue(ctx context.Context, repo repository.Repository, task *models.Task) (string, error) {
+	workflow, err := projectWorkflow(ctx, repo, task.ProjectID)
+	if err != nil {
+		return "", err
+	}
+	for _, w := range []*models.Workflow{workflow, models.DefaultWorkflow(task.ProjectID)} {
+		for _, status := range w.Statuses {
+			if status.Name == task.Status {
+				return strconv.Itoa(status.Position), nil
+			}
+		}
+	}
+	return "-1", nil
+}
+
+// applyTaskQuery copies the user-supplied filters of query onto filter.
+func applyTaskQuery(filter repository.TaskFilter, query TaskQuery) repository.TaskFilter {
+	filter.Statuses = query.Statuses
+	filter.Priorities = query.Priorities
+	filter.AssigneeID = query.AssigneeID
+	filter.Unassigned = query.Unassigned
+	filter.Search = query.Search
+	filter.TopLevel = query.TopLevel
+	filter.LabelIDs = uniqueIDs(query.LabelIDs)
+	filter.AllLabels = query.AllLabels
+	filter.CreatedAfter = query.CreatedAfter
+	filter.CreatedBefore = query.CreatedBefore
+	filter.UpdatedAfter = query.UpdatedAfter
+	filter.UpdatedBefore = query.UpdatedBefore
+	filter.DueAfter = query.DueAfter
+	filter.DueBefore = query.DueBefore
+
+	loc := query.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	now := time.Now().In(loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	switch query.Due {
+	case dueOverdue:
+		filter.OverdueAt = &now
+		filter.DueAfter, filter.DueBefore = nil, nil
+	case dueToday:
+		end := today.AddDate(0, 0, 1)
+		filter.DueAfter, filter.DueBefore = &today, &end
+	case dueThisWeek:
+		// time.Weekday counts from Sunday; weeks here start on Monday.
+		start := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		end := start.AddDate(0, 0, 7)
+		filter.DueAfter, filter.DueBefore = &start, &end
+	}
+	return filter
+}
+
+// uniqueIDs drops repeated IDs, keeping the first occurrence of each.
+func uniqueIDs(ids []uint) []uint {
+	if len(ids) == 0 {
+		return nil
+	}
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// Values accepted for TaskQuery.Due.
+const (
+	dueOverdue  = "overdue"
+	dueToday    = "today"
+	dueThisWeek = "this_week"
+)
+
+// annotateTask fills in the computed fields of a single task.
+func (s *TaskService) annotateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	tasks := []models.Task{*task}
+	if err := annotateTasks(ctx, s.repo, tasks); err != nil {
+		return nil, err
+	}
+	return &tasks[0], nil
+}
+
+// annotateTasks fills in the computed fields of tasks: their subtask progress,
+// whether they are blocked and their custom field values.
+func annotateTasks(ctx context.Context, repo repository.Repository, tasks []models.Task) error {
+	if err := attachProgress(ctx, repo, tasks); err != nil {
+		return err
+	}
+	if err := attachBlocked(ctx, repo, tasks); err != nil {
+		return err
+	}
+	return attachCustomFields(ctx, repo, tasks)
+}
+
+// validateDates checks that a task does not start after it is due.
+func validateDates(start, due *time.Time) error {
+	if start != nil && due != nil && start.After(*due) {
+		return fmt.Errorf("%w: start_date must not be after due_date", ErrInvalidInput)
+	}
+	return nil
+}
+
+// utcTime normalizes a user-supplied time to UTC, keeping nil as nil.
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// decodeTaskCursor turns a cursor back into the typed position the repository
+// seeks to. The cursor must have been issued for the same ordering; field is
+// the custom field being sorted by, if any.
+func decodeTaskCursor(s string, sort repository.TaskSort, field *models.CustomField, desc bool) (*repository.TaskCursor, error) {
+	cursor, err := decodeCursor(s)
+	if err != nil {
+		return nil, err
+	}
+	if cursor.Sort != string(sort) || cursor.Desc != desc {
+		return nil, fmt.Errorf("%w: cursor was issued for a different sort order", ErrInvalidInput)
+	}
+
+	after := &repository.TaskCursor{ID: cursor.ID}
+	switch {
+	case field != nil:
+		after.Value, err = decodeCustomSortValue(field, cursor.Value)
+	case sort == repository.TaskSortCreatedAt || sort == repository.TaskSortUpdatedAt:
+		after.Value, err = time.Parse(time.RFC3339Nano, cursor.Value)
+	case sort == repository.TaskSortPriority || sort == repository.TaskSortStatus:
+		after.Value, err = strconv.Atoi(cursor.Value)
+	default:
+		after.Value = cursor.Value
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidInput)
+	}
+	return after, nil
+}
+
+func (s *TaskService) CreateTask(ctx context.Context, actor Actor, input CreateTaskInput) (*models.Task, error) {
+	project, err := loadProject(ctx, s.repo, actor, input.ProjectID, models.WorkspaceRoleDev)
+	if err != nil {
+		return nil, err
+	}
+	if input.AssigneeID != nil {
+		if err := requireMember(ctx, s.repo, project.WorkspaceID, *input.AssigneeID); err != nil {
+			return nil, err
+		}
+	}
+
+	workflow, err := projectWorkflow(ctx, s.repo, project.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Set defaults
 	if input.Status == "" {
-		input.Status = models.TaskStatusTodo
+		input.Status = workflow.InitialStatus()
+	} else if err := validateStatus(workflow, input.Status); err != nil {
+		return nil, err
 	}
 	if input.Priority == "" {
 		input.Priority = models.TaskPriorityMedium
+	} else if err := validatePriority(input.Priority); err != nil {
+		return nil, err
+	}
+
+	if err := validateDates(input.StartDate, input.DueDate); err != nil {
+		return nil, err
 	}
 
 	task := &models.Task{
@@ -49,24 +318,68 @@ func (s *TaskService) CreateTask(ctx context.Context, input CreateTaskInput) (*m
 		Priority:    input.Priority,
 		AssigneeID:  input.AssigneeID,
 		ProjectID:   input.ProjectID,
+		CreatedByID: &actor.UserID,
+		ParentID:    input.ParentID,
+		StartDate:   utcTime(input.StartDate),
+		DueDate:     utcTime(input.DueDate),
+	}
+	if workflow.IsDone(task.Status) {
+		now := time.Now()
+		task.CompletedAt = &now
+	}
+	if task.ParentID != nil {
+		if err := checkParent(ctx, s.repo, 0, task.ProjectID, *task.ParentID, task.CompletedAt != nil); err != nil {
+			return nil, err
+		}
+	}
+	customChanges, err := resolveCustomFields(ctx, s.repo, project, input.CustomFields, true)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.repo.Tasks().Create(ctx, task); err != nil {
-		return nil, fmt.Errorf("failed to create task: %w", err)
+	err = s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if err := tx.Tasks().Create(ctx, task); err != nil {
+			return fmt.Errorf("failed to create task: %w", err)
+		}
+		if err := saveCustomFields(ctx, tx, task.ID, customChanges); err != nil {
+			return err
+		}
+		fields := taskFields(task)
+		for _, change := range customChanges {
+			if change.Value != nil {
+				fields[customHistoryKey(change.Field)] = customHistoryValue(change.Field, change.Value)
+			}
+		}
+		return recordTaskHistory(ctx, tx, task.ID, actor.UserID, models.HistoryChangeCreate, nil, fields)
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	task, err = s.annotateTask(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	s.publishTask(ctx, models.EventTaskCreated, project.WorkspaceID, task)
+	s.notifyTaskChange(ctx, actor, task, nil, task.Status, nil)
 	return task, nil
 }
 
-func (s *TaskService) GetTask(ctx context.Context, id uint) (*models.Task, error) {
-	return s.repo.Tasks().GetByID(ctx, id)
+func (s *TaskService) GetTask(ctx context.Context, actor Actor, id uint) (*models.Task, error) {
+	task, err := loadTask(ctx, s.repo, actor, id, models.WorkspaceRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return s.annotateTask(ctx, task)
 }
 
-func (s *TaskService) UpdateTask(ctx context.Context, id uint, input UpdateTaskInput) (*models.Task, error) {
-	task, err := s.repo.Tasks().GetByID(ctx, id)
+func (s *TaskService) UpdateTask(ctx context.Context, actor Actor, id uint, input UpdateTaskInput) (*models.Task, error) {
+	task, err := loadTask(ctx, s.repo, actor, id, models.WorkspaceRoleDev)
 	if err != nil {
-		return nil, fmt.Errorf("task not found: %w", err)
+		return nil, err
 	}
+	before := taskFields(task)
+	previousAssignee, previousStatus := task.AssigneeID, task.Status
 
 	// Update fields if provided
 	if input.Title != nil {
@@ -75,38 +388,416 @@ func (s *TaskService) UpdateTask(ctx context.Context, id uint, input UpdateTaskI
 	if input.Description != nil {
 		task.Description = *input.Description
 	}
-	if input.Status != nil {
+	if input.Status != nil && *input.Status != task.Status {
+		workflow, err := projectWorkflow(ctx, s.repo, task.ProjectID)
+		if err != nil {
+			return nil, err
+		}
+		role, err := actorWorkspaceRole(ctx, s.repo, actor, task.Project.WorkspaceID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkTransition(workflow, task.Status, *input.Status, role); err != nil {
+			return nil, err
+		}
+		// Starting a task means moving it into any status that is neither
+		// the workflow's initial status nor a done one.
+		started := *input.Status != workflow.InitialStatus() && !workflow.IsDone(*input.Status)
+		if started && task.Project.EnforceDependencies {
+			if err := requireUnblocked(ctx, s.repo, task.ID); err != nil {
+				return nil, err
+			}
+		}
 		task.Status = *input.Status
+
+		if !workflow.IsDone(task.Status) {
+			// Reopened tasks are checked against their due date again.
+			task.CompletedAt = nil
+			task.OverdueAt = nil
+		} else if task.CompletedAt == nil {
+			if err := requireSubtasksDone(ctx, s.repo, task.ID); err != nil {
+				return nil, err
+			}
+			now := time.Now()
+			task.CompletedAt = &now
+		}
 	}
 	if input.Priority != nil {
+		if err := validatePriority(*input.Priority); err != nil {
+			return nil, err
+		}
 		task.Priority = *input.Priority
 	}
 	if input.AssigneeID != nil {
+		if err := requireMember(ctx, s.repo, task.Project.WorkspaceID, *input.AssigneeID); err != nil {
+			return nil, err
+		}
 		task.AssigneeID = input.AssigneeID
 	}
+	if input.ClearStartDate {
+		task.StartDate = nil
+	} else if input.StartDate != nil {
+		task.StartDate = utcTime(input.StartDate)
+	}
+	if input.ClearDueDate {
+		task.DueDate = nil
+	} else if input.DueDate != nil {
+		task.DueDate = utcTime(input.DueDate)
+	}
+	if err := validateDates(task.StartDate, task.DueDate); err != nil {
+		return nil, err
+	}
+	if task.DueDate == nil || task.DueDate.After(time.Now()) {
+		task.OverdueAt = nil
+	}
+
+	customChanges, err := resolveCustomFields(ctx, s.repo, &task.Project, input.CustomFields, false)
+	if err != nil {
+		return nil, err
+	}
+	after := taskFields(task)
+	if len(customChanges) > 0 {
+		stored, err := loadCustomValues(ctx, s.repo, task.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range customChanges {
+			key := customHistoryKey(change.Field)
+			before[key] = customHistoryValue(change.Field, stored[change.Field.ID])
+			after[key] = customHistoryValue(change.Field, change.Value)
+		}
+	}
+
+	previous, current := diffFields(before, after)
+	if len(current) == 0 {
+		return s.annotateTask(ctx, task)
+	}
 
-	if err := s.repo.Tasks().Update(ctx, task); err != nil {
-		return nil, fmt.Errorf("failed to update task: %w", err)
+	err = s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if err := tx.Tasks().Update(ctx, task); err != nil {
+			return fmt.Errorf("failed to update task: %w", err)
+		}
+		if err := saveCustomFields(ctx, tx, task.ID, customChanges); err != nil {
+			return err
+		}
+		return recordTaskHistory(ctx, tx, task.ID, actor.UserID, models.HistoryChangeUpdate, previous, current)
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	task, err = s.repo.Tasks().GetByID(ctx, task.ID)
+	if err != nil {
+		return nil, err
+	}
+	task, err = s.annotateTask(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	s.publishTask(ctx, models.EventTaskUpdated, task.Project.WorkspaceID, task)
+	s.notifyTaskChange(ctx, actor, task, previousAssignee, previousStatus, current)
 	return task, nil
 }
 
-func (s *TaskService) ListProjectTasks(ctx context.Context, projectID uint) ([]models.Task, error) {
-	return s.repo.Tasks().ListByProjectID(ctx, projectID)
+// notifyTaskChange tells a newly assigned user that the task is theirs, and
+// the task's creator and previous assignee that it changed status or was
+// otherwise updated. changed holds the new values of the changed fields.
+func (s *TaskService) notifyTaskChange(ctx context.Context, actor Actor, task *models.Task, previousAssignee *uint, previousStatus models.TaskStatus, changed map[string]interface{}) {
+	var assigned uint
+	if task.AssigneeID != nil && (previousAssignee == nil || *previousAssignee != *task.AssigneeID) {
+		assigned = *task.AssigneeID
+		s.notifications.notify(ctx, notification{
+			Kind:    models.NotificationAssigned,
+			ActorID: actor.UserID,
+			TaskID:  task.ID,
+		}, assigned)
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	var watchers []uint
+	for _, userID := range []*uint{task.CreatedByID, previousAssignee} {
+		// The new assignee already heard about the task.
+		if userID != nil && *userID != assigned {
+			watchers = append(watchers, *userID)
+		}
+	}
+	n := notification{ActorID: actor.UserID, TaskID: task.ID}
+	if previousStatus != task.Status {
+		n.Kind = models.NotificationStatusChanged
+		n.From, n.To = previousStatus, task.Status
+	} else {
+		fields := make([]string, 0, len(changed))
+		for field := range changed {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		n.Kind = models.NotificationTaskUpdated
+		n.Detail = "Changed " + strings.Join(fields, ", ")
+	}
+	s.notifications.notify(ctx, n, watchers...)
 }
 
-func (s *TaskService) AssignTask(ctx context.Context, taskID uint, assigneeID uint) (*models.Task, error) {
-	task, err := s.repo.Tasks().GetByID(ctx, taskID)
+func (s *TaskService) ListProjectTasks(ctx context.Context, actor Actor, projectID uint, query TaskQuery) (*TaskPage, error) {
+	if _, err := loadProject(ctx, s.repo, actor, projectID, models.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+	if err := validateQueryStatuses(ctx, s.repo, projectID, query.Statuses); err != nil {
+		return nil, err
+	}
+	return s.listTasks(ctx, repository.TaskFilter{ProjectID: projectID}, query)
+}
+
+// validateQueryStatuses checks that every status filtered on is part of the
+// project's workflow.
+func validateQueryStatuses(ctx context.Context, repo repository.Repository, projectID uint, statuses []models.TaskStatus) error {
+	if len(statuses) == 0 {
+		return nil
+	}
+	workflow, err := projectWorkflow(ctx, repo, projectID)
 	if err != nil {
-		return nil, fmt.Errorf("task not found: %w", err)
+		return err
+	}
+	for _, status := range statuses {
+		if err := validateStatus(workflow, status); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// ListMyTasks pages through the tasks assigned to the actor across every
+// workspace they belong to. With groupByProject the page also carries the
+// number of matching tasks in each project.
+func (s *TaskService) ListMyTasks(ctx context.Context, actor Actor, query TaskQuery, groupByProject bool) (*MyTasksPage, error) {
+	query.AssigneeID = actor.UserID
+	query.Unassigned = false
+
+	filter := repository.TaskFilter{}
+	if !actor.IsAdmin() {
+		filter.MemberID = actor.UserID
+	}
+
+	page, err := s.listTasks(ctx, filter, query)
+	if err != nil {
+		return nil, err
+	}
+	result := &MyTasksPage{TaskPage: *page}
+
+	if groupByProject {
+		counts, err := s.repo.Tasks().CountByProject(ctx, applyTaskQuery(filter, query))
+		if err != nil {
+			return nil, fmt.Errorf("failed to group tasks: %w", err)
+		}
+		result.Groups = make([]TaskGroup, 0, len(counts))
+		for _, count := range counts {
+			result.Groups = append(result.Groups, TaskGroup{
+				ProjectID:   count.ProjectID,
+				ProjectName: count.ProjectName,
+				WorkspaceID: count.WorkspaceID,
+				Count:       count.Count,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// listTasks applies the user-supplied query on top of filter and returns one
+// page of results with the cursor for the next one.
+func (s *TaskService) listTasks(ctx context.Context, filter repository.TaskFilter, query TaskQuery) (*TaskPage, error) {
+	sort := repository.TaskSort(query.Sort)
+	if sort == "" {
+		sort = repository.TaskSortCreatedAt
+	}
+	if _, ok := taskSortValues[sort]; !ok && sort != repository.TaskSortStatus && !strings.HasPrefix(query.Sort, customSortPrefix) {
+		return nil, &ValidationError{
+			Field:   "sort",
+			Value:   string(sort),
+			Allowed: []string{"created_at", "updated_at", "title", "priority", "status"},
+		}
+	}
+	for _, priority := range query.Priorities {
+		if err := validatePriority(priority); err != nil {
+			return nil, err
+		}
+	}
+	switch query.Due {
+	case "", dueOverdue, dueToday, dueThisWeek:
+	default:
+		return nil, &ValidationError{Field: "due", Value: query.Due, Allowed: []string{dueOverdue, dueToday, dueThisWeek}}
+	}
+	_, pageSize := normalizePage(1, query.PageSize)
+
+	filter = applyTaskQuery(filter, query)
+	customSort, err := applyCustomQuery(ctx, s.repo, &filter, query)
+	if err != nil {
+		return nil, err
+	}
+	filter.Sort = sort
+	filter.Desc = query.Desc
+	// Fetch one extra row to learn whether another page follows.
+	filter.Limit = pageSize + 1
+
+	if query.Cursor != "" {
+		after, err := decodeTaskCursor(query.Cursor, sort, customSort, query.Desc)
+		if err != nil {
+			return nil, err
+		}
+		filter.After = after
+	}
+
+	tasks, err := s.repo.Tasks().List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	if err := annotateTasks(ctx, s.repo, tasks); err != nil {
+		return nil, err
+	}
+
+	page := &TaskPage{Items: tasks, PageSize: pageSize}
+	if len(tasks) > pageSize {
+		page.Items = tasks[:pageSize]
+		page.HasMore = true
+		last := page.Items[pageSize-1]
+		var value string
+		switch {
+		case customSort != nil:
+			value = customSortValue(customSort, &last)
+		case sort == repository.TaskSortStatus:
+			if value, err = statusSortValue(ctx, s.repo, &last); err != nil {
+				return nil, err
+			}
+		default:
+			value = taskSortValues[sort](&last)
+		}
+		page.NextCursor = encodeCursor(pageCursor{
+			Sort:  string(sort),
+			Desc:  query.Desc,
+			Value: value,
+			ID:    last.ID,
+		})
+	}
+	return page, nil
+}
+
+func (s *TaskService) AssignTask(ctx context.Context, actor Actor, taskID uint, assigneeID uint) (*models.Task, error) {
+	task, err := loadTask(ctx, s.repo, actor, taskID, models.WorkspaceRoleManager)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireMember(ctx, s.repo, task.Project.WorkspaceID, assigneeID); err != nil {
+		return nil, err
+	}
+	before := taskFields(task)
+	previousAssignee := task.AssigneeID
 
 	task.AssigneeID = &assigneeID
 
-	if err := s.repo.Tasks().Update(ctx, task); err != nil {
-		return nil, fmt.Errorf("failed to assign task: %w", err)
+	previous, current := diffFields(before, taskFields(task))
+	if len(current) == 0 {
+		return s.annotateTask(ctx, task)
+	}
+
+	err = s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if err := tx.Tasks().Update(ctx, task); err != nil {
+			return fmt.Errorf("failed to assign task: %w", err)
+		}
+		return recordTaskHistory(ctx, tx, task.ID, actor.UserID, models.HistoryChangeAssign, previous, current)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	task, err = s.repo.Tasks().GetByID(ctx, task.ID)
+	if err != nil {
+		return nil, err
+	}
+	task, err = s.annotateTask(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	s.publishTask(ctx, models.EventTaskAssigned, task.Project.WorkspaceID, task)
+	s.notifyTaskChange(ctx, actor, task, previousAssignee, task.Status, current)
+	return task, nil
+}
+
+// DeleteTask archives a task together with its subtasks. The task keeps its
+// history and can be brought back with RestoreTask.
+func (s *TaskService) DeleteTask(ctx context.Context, actor Actor, id uint) error {
+	task, err := loadTask(ctx, s.repo, actor, id, models.WorkspaceRoleDev)
+	if err != nil {
+		return err
+	}
+	at := archiveTimestamp()
+
+	err = s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if err := tx.Tasks().Archive(ctx, task.ID, at); err != nil {
+			return fmt.Errorf("failed to delete task: %w", err)
+		}
+		if err := tx.Tasks().ArchiveDescendants(ctx, task.ID, at); err != nil {
+			return fmt.Errorf("failed to delete subtasks: %w", err)
+		}
+		return recordTaskHistory(ctx, tx, task.ID, actor.UserID, models.HistoryChangeDelete, taskFields(task), nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publishTask(ctx, models.EventTaskDeleted, task.Project.WorkspaceID, task)
+	return nil
+}
+
+// RestoreTask brings back an archived task and the subtasks archived along with
+// it. A task cannot be restored while its project or parent task is archived.
+func (s *TaskService) RestoreTask(ctx context.Context, actor Actor, id uint) (*models.Task, error) {
+	task, err := s.repo.Tasks().GetIncludingArchived(ctx, id)
+	if err != nil {
+		return nil, lookupError("task", err)
+	}
+	if err := authorizeWorkspace(ctx, s.repo, actor, task.Project.WorkspaceID, models.WorkspaceRoleDev, "task"); err != nil {
+		return nil, err
+	}
+	if !task.DeletedAt.Valid {
+		return nil, fmt.Errorf("%w: task is not archived", ErrConflict)
+	}
+	if task.Project.DeletedAt.Valid {
+		return nil, fmt.Errorf("%w: restore the project first", ErrConflict)
+	}
+	if task.ParentID != nil {
+		parent, err := s.repo.Tasks().GetIncludingArchived(ctx, *task.ParentID)
+		if err != nil {
+			return nil, lookupError("parent task", err)
+		}
+		if parent.DeletedAt.Valid {
+			return nil, fmt.Errorf("%w: restore the parent task first", ErrConflict)
+		}
+	}
+	at := task.DeletedAt.Time
+
+	err = s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if err := tx.Tasks().Restore(ctx, task.ID); err != nil {
+			return fmt.Errorf("failed to restore task: %w", err)
+		}
+		if err := tx.Tasks().RestoreDescendants(ctx, task.ID, at); err != nil {
+			return fmt.Errorf("failed to restore subtasks: %w", err)
+		}
+		return recordTaskHistory(ctx, tx, task.ID, actor.UserID, models.HistoryChangeRestore, nil, taskFields(task))
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	task, err = s.repo.Tasks().GetByID(ctx, task.ID)
+	if err != nil {
+		return nil, err
+	}
+	task, err = s.annotateTask(ctx, task)
+	if err != nil {
+		return nil, err
+	}
+	s.publishTask(ctx, models.EventTaskRestored, task.Project.WorkspaceID, task)
 	return task, nil
 }
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/auth"
+	"gorm.io/gorm"
+)
+
+type TokenService struct {
+	repo repository.Repository
+	keys auth.KeyManager
+}
+
+func NewTokenService(repo repository.Repository, keys auth.KeyManager) *TokenService {
+	return &TokenService{
+		repo: repo,
+		keys: keys,
+	}
+}
+
+// TokenPair is an access token together with the refresh token that can be
+// exchanged for the next pair.
+type TokenPair struct {
+	AccessToken  string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"` // Expiry of the access token
+}
+
+// IssueTokens starts a new session for user, e.g. after a login.
+func (s *TokenService) IssueTokens(ctx context.Context, user *models.User) (*TokenPair, error) {
+	familyID, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token family: %w", err)
+	}
+	return s.issue(ctx, s.repo, user, familyID, nil)
+}
+
+// Refresh exchanges a refresh token for a new token pair. The presented token
+// is revoked. Presenting an already revoked token is treated as token theft
+// and revokes every token in its family.
+func (s *TokenService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, *models.User, error) {
+	stored, err := s.repo.RefreshTokens().GetByHash(ctx, auth.HashRefreshToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, fmt.Errorf("%w: invalid refresh token", ErrUnauthenticated)
+		}
+		return nil, nil, fmt.Errorf("failed to load refresh token: %w", err)
+	}
+
+	if stored.RevokedAt != nil {
+		if err := s.repo.RefreshTokens().RevokeFamily(ctx, stored.FamilyID); err != nil {
+			return nil, nil, fmt.Errorf("failed to revoke token family: %w", err)
+		}
+		return nil, nil, fmt.Errorf("%w: refresh token has been revoked", ErrUnauthenticated)
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, nil, fmt.Errorf("%w: refresh token has expired", ErrUnauthenticated)
+	}
+
+	user, err := s.repo.Users().GetByID(ctx, stored.UserID)
+	if err != nil {
+		return nil, nil, lookupError("user", err)
+	}
+	if user.IsDisabled() {
+		return nil, nil, fmt.Errorf("%w: account disabled", ErrUnauthenticated)
+	}
+
+	var pair *TokenPair
+	err = s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		var err error
+		pair, err = s.issue(ctx, tx, user, stored.FamilyID, stored)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pair, user, nil
+}
+
+// Logout revokes the access token identified by tokenID until it expires and,
+// if given, the refresh token of the same session.
+func (s *TokenService) Logout(ctx context.Context, actor Actor, tokenID string, expiresAt time.Time, refreshToken string) error {
+	return s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if err := tx.RevokedTokens().Create(ctx, &models.RevokedToken{TokenID: tokenID, ExpiresAt: expiresAt}); err != nil {
+			return fmt.Errorf("failed to revoke access token: %w", err)
+		}
+		if refreshToken == "" {
+			return nil
+		}
+
+		stored, err := tx.RefreshTokens().GetByHash(ctx, auth.HashRefreshToken(refreshToken))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return fmt.Errorf("failed to load refresh token: %w", err)
+		}
+		if stored.UserID != actor.UserID {
+			return nil
+		}
+		if err := tx.RefreshTokens().RevokeFamily(ctx, stored.FamilyID); err != nil {
+			return fmt.Errorf("failed to revoke refresh token: %w", err)
+		}
+		return nil
+	})
+}
+
+// RevokeUserSessions revokes every refresh token of a user so no new access
+// tokens can be obtained, e.g. after the account is disabled.
+func (s *TokenService) RevokeUserSessions(ctx context.Context, userID uint) error {
+	return revokeUserSessions(ctx, s.repo, userID)
+}
+
+// JWKS returns the public keys tokens are signed with.
+func (s *TokenService) JWKS() auth.JSONWebKeySet {
+	return auth.JWKS(s.keys)
+}
+
+// issue creates a token pair in familyID. When previous is set it is revoked
+// and linked to the new refresh token.
+func (s *TokenService) issue(ctx context.Context, repo repository.Repository, user *models.User, familyID string, previous *models.RefreshToken) (*TokenPair, error) {
+	accessToken, claims, err := auth.GenerateToken(s.keys, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+	refreshToken, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	stored := &models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: auth.HashRefreshToken(refreshToken),
+		FamilyID:  familyID,
+		ExpiresAt: time.Now().Add(auth.RefreshTokenTTL()),
+	}
+	if err := repo.RefreshTokens().Create(ctx, stored); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	if previous != nil {
+		// The conditional update makes concurrent refreshes of the same token
+		// race safely: only one of them can rotate it.
+		rotated, err := repo.RefreshTokens().MarkReplaced(ctx, previous.ID, stored.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+		}
+		if !rotated {
+			return nil, fmt.Errorf("%w: refresh token has been revoked", ErrUnauthenticated)
+		}
+	}
+
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    claims.ExpiresAt.Time,
+	}, nil
+}
+
+func revokeUserSessions(ctx context.Context, repo repository.Repository, userID uint) error {
+	if err := repo.RefreshTokens().RevokeAllForUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return nil
+}
+
+// StartTokenCleanup launches a background goroutine that periodically deletes
+// expired refresh tokens and revocation entries for access tokens that have
+// expired anyway.
+func StartTokenCleanup(repo repository.Repository, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			<-ticker.C
+			ctx := context.Background()
+			now := time.Now()
+			if err := repo.RefreshTokens().DeleteExpired(ctx, now); err != nil {
+				log.Printf("Token cleanup: failed to delete expired refresh tokens: %v", err)
+			}
+			if err := repo.RevokedTokens().DeleteExpired(ctx, now); err != nil {
+				log.Printf("Token cleanup: failed to delete expired revocations: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,394 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/auth"
+	"gorm.io/gorm"
+)
+
+type UserService struct {
+	repo repository.Repository
+}
+
+func NewUserService(repo repository.Repository) *UserService {
+	return &UserService{repo: repo}
+}
+
+// AuditLogPage is a single page of the audit log.
+type AuditLogPage struct {
+	Items    []models.AuditLog `json:"items"`
+	Total    int64             `json:"total"`
+	Page     int               `json:"page"`
+	PageSize int               `json:"page_size"`
+}
+
+// UserPage is a single page of a user listing.
+type UserPage struct {
+	Items    []models.User `json:"items"`
+	Total    int64         `json:"total"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+}
+
+// UserQuery holds the user-supplied filters for a user listing.
+type UserQuery struct {
+	Search   string
+	Role     models.UserRole
+	Page     int
+	PageSize int
+}
+
+// BootstrapAdmin guarantees that an enabled admin account exists. It does
+// nothing if there already is one. Otherwise the user registered under email is promoted,
+// or created with username and password if no such user exists. The returned
+// user is nil when no change was made.
+func (s *UserService) BootstrapAdmin(ctx context.Context, email, username, password string) (*models.User, error) {
+	admins, err := s.repo.Users().CountEnabledByRole(ctx, models.RoleAdmin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count admins: %w", err)
+	}
+	if admins > 0 {
+		return nil, nil
+	}
+
+	user, err := s.repo.Users().GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+
+	previousRole := ""
+	if user == nil {
+		if password == "" {
+			return nil, fmt.Errorf("%w: a password is required to create the bootstrap admin", ErrInvalidInput)
+		}
+		hashedPassword, err := auth.HashPassword(password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+		user = &models.User{
+			Username:     username,
+			Email:        email,
+			PasswordHash: hashedPassword,
+		}
+	} else {
+		previousRole = string(user.Role)
+	}
+	user.Role = models.RoleAdmin
+
+	err = s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if user.ID == 0 {
+			if err := tx.Users().Create(ctx, user); err != nil {
+				return fmt.Errorf("failed to create admin: %w", err)
+			}
+		} else if err := tx.Users().Update(ctx, user); err != nil {
+			return fmt.Errorf("failed to promote admin: %w", err)
+		}
+		return tx.AuditLogs().Create(ctx, &models.AuditLog{
+			Action:        models.AuditActionAdminBootstrap,
+			TargetType:    "user",
+			TargetID:      user.ID,
+			PreviousValue: previousRole,
+			NewValue:      string(models.RoleAdmin),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ChangeRole sets a user's global role and records the change in the audit
+// log. The last enabled admin cannot be demoted.
+func (s *UserService) ChangeRole(ctx context.Context, actor Actor, userID uint, role models.UserRole) (*models.User, error) {
+	if role != models.RoleAdmin && role != models.RoleManager && role != models.RoleDev {
+		return nil, fmt.Errorf("%w: role must be one of admin, manager, dev", ErrInvalidInput)
+	}
+
+	user, err := s.repo.Users().GetByID(ctx, userID)
+	if err != nil {
+		return nil, lookupError("user", err)
+	}
+	if user.Role == role {
+		return user, nil
+	}
+
+	previousRole := user.Role
+	user.Role = role
+
+	err = s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if previousRole == models.RoleAdmin && !user.IsDisabled() {
+			if err := requireAnotherAdmin(ctx, tx, "demote"); err != nil {
+				return err
+			}
+		}
+		if err := tx.Users().Update(ctx, user); err != nil {
+			return fmt.Errorf("failed to update user role: %w", err)
+		}
+		return tx.AuditLogs().Create(ctx, &models.AuditLog{
+			ActorID:       &actor.UserID,
+			Action:        models.AuditActionUserRoleChanged,
+			TargetType:    "user",
+			TargetID:      user.ID,
+			PreviousValue: string(previousRole),
+			NewValue:      string(role),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *UserService) ListAuditLogs(ctx context.Context, page, pageSize int) (*AuditLogPage, error) {
+	page, pageSize = normalizePage(page, pageSize)
+
+	entries, total, err := s.repo.AuditLogs().List(ctx, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+
+	return &AuditLogPage{
+		Items:    entries,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+func (s *UserService) ListUsers(ctx context.Context, query UserQuery) (*UserPage, error) {
+	page, pageSize := normalizePage(query.Page, query.PageSize)
+
+	users, total, err := s.repo.Users().List(ctx, repository.UserFilter{
+		Search: query.Search,
+		Role:   query.Role,
+		Limit:  pageSize,
+		Offset: (page - 1) * pageSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	return &UserPage{
+		Items:    users,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+func (s *UserService) GetUser(ctx context.Context, id uint) (*models.User, error) {
+	user, err := s.repo.Users().GetByID(ctx, id)
+	if err != nil {
+		return nil, lookupError("user", err)
+	}
+	return user, nil
+}
+
+// SetDisabled disables or re-enables a user account. Disabled users can no
+// longer log in, their existing tokens are rejected and their refresh tokens
+// are revoked. Admins cannot disable themselves or the last enabled admin.
+func (s *UserService) SetDisabled(ctx context.Context, actor Actor, id uint, disabled bool) (*models.User, error) {
+	if disabled && id == actor.UserID {
+		return nil, fmt.Errorf("%w: you cannot disable your own account", ErrConflict)
+	}
+
+	user, err := s.repo.Users().GetByID(ctx, id)
+	if err != nil {
+		return nil, lookupError("user", err)
+	}
+	if user.IsDisabled() == disabled {
+		return user, nil
+	}
+
+	action := models.AuditActionUserEnabled
+	user.DisabledAt = nil
+	if disabled {
+		now := time.Now()
+		action = models.AuditActionUserDisabled
+		user.DisabledAt = &now
+	}
+
+	err = s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if disabled && user.Role == models.RoleAdmin {
+			if err := requireAnotherAdmin(ctx, tx, "disable"); err != nil {
+				return err
+			}
+		}
+		if err := tx.Users().Update(ctx, user); err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+		if disabled {
+			if err := revokeUserSessions(ctx, tx, user.ID); err != nil {
+				return err
+			}
+		}
+		return tx.AuditLogs().Create(ctx, &models.AuditLog{
+			ActorID:    &actor.UserID,
+			Action:     action,
+			TargetType: "user",
+			TargetID:   user.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ForcePasswordReset replaces a user's password with a random temporary one
+// and flags the account so the user must choose a new password before using
+// the API again. The temporary password is returned so it can be handed over.
+func (s *UserService) ForcePasswordReset(ctx context.Context, actor Actor, id uint) (string, error) {
+	user, err := s.repo.Users().GetByID(ctx, id)
+	if err != nil {
+		return "", lookupError("user", err)
+	}
+
+	temporaryPassword, err := auth.GenerateRandomPassword()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	hashedPassword, err := auth.HashPassword(temporaryPassword)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user.PasswordHash = hashedPassword
+	user.PasswordResetRequired = true
+
+	err = s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if err := tx.Users().Update(ctx, user); err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+		if err := revokeUserSessions(ctx, tx, user.ID); err != nil {
+			return err
+		}
+		return tx.AuditLogs().Create(ctx, &models.AuditLog{
+			ActorID:    &actor.UserID,
+			Action:     models.AuditActionUserPasswordReset,
+			TargetType: "user",
+			TargetID:   user.ID,
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return temporaryPassword, nil
+}
+
+// DeleteUser anonymizes and disables a user account. The row itself is kept
+// so task history stays intact. Tasks assigned to the user are handed to
+// reassignTo when given, or unassigned otherwise, and the user loses every
+// workspace membership. Users who still own a workspace, even an archived
+// one, and the last enabled admin cannot be deleted.
+func (s *UserService) DeleteUser(ctx context.Context, actor Actor, id uint, reassignTo *uint) error {
+	if id == actor.UserID {
+		return fmt.Errorf("%w: you cannot delete your own account", ErrConflict)
+	}
+	if reassignTo != nil && *reassignTo == id {
+		return fmt.Errorf("%w: cannot reassign tasks to the user being deleted", ErrInvalidInput)
+	}
+
+	user, err := s.repo.Users().GetByID(ctx, id)
+	if err != nil {
+		return lookupError("user", err)
+	}
+
+	// Archived workspaces count too: restoring one must not bring it back
+	// without an owner.
+	owned, err := s.repo.Workspaces().ListOwnedByUserID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if len(owned) > 0 {
+		return fmt.Errorf("%w: user still owns workspace %d", ErrConflict, owned[0].ID)
+	}
+
+	tasks, err := s.repo.Tasks().ListByAssigneeID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to load assigned tasks: %w", err)
+	}
+	if reassignTo != nil {
+		for _, task := range tasks {
+			if err := requireMember(ctx, s.repo, task.Project.WorkspaceID, *reassignTo); err != nil {
+				return err
+			}
+		}
+	}
+
+	enabledAdmin := user.Role == models.RoleAdmin && !user.IsDisabled()
+	now := time.Now()
+	user.Username = fmt.Sprintf("deleted-user-%d", user.ID)
+	user.Email = fmt.Sprintf("deleted-user-%d@deleted.invalid", user.ID)
+	user.DisabledAt = &now
+	user.PasswordResetRequired = false
+	// Replace the hash with one for a password nobody knows.
+	unusable, err := auth.GenerateRandomPassword()
+	if err != nil {
+		return fmt.Errorf("failed to generate password: %w", err)
+	}
+	if user.PasswordHash, err = auth.HashPassword(unusable); err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if enabledAdmin {
+			if err := requireAnotherAdmin(ctx, tx, "delete"); err != nil {
+				return err
+			}
+		}
+		for i := range tasks {
+			task := &tasks[i]
+			before := taskFields(task)
+			task.AssigneeID = reassignTo
+			previous, current := diffFields(before, taskFields(task))
+			if err := tx.Tasks().Update(ctx, task); err != nil {
+				return fmt.Errorf("failed to reassign task %d: %w", task.ID, err)
+			}
+			if err := recordTaskHistory(ctx, tx, task.ID, actor.UserID, models.HistoryChangeAssign, previous, current); err != nil {
+				return err
+			}
+		}
+		if err := tx.WorkspaceMembers().DeleteByUserID(ctx, user.ID); err != nil {
+			return fmt.Errorf("failed to remove workspace memberships: %w", err)
+		}
+		if err := tx.Users().Update(ctx, user); err != nil {
+			return fmt.Errorf("failed to anonymize user: %w", err)
+		}
+		if err := revokeUserSessions(ctx, tx, user.ID); err != nil {
+			return err
+		}
+		return tx.AuditLogs().Create(ctx, &models.AuditLog{
+			ActorID:    &actor.UserID,
+			Action:     models.AuditActionUserDeleted,
+			TargetType: "user",
+			TargetID:   user.ID,
+		})
+	})
+}
+
+// requireAnotherAdmin fails with ErrConflict unless there are at least two
+// enabled admins, so that one of them can be demoted, disabled or deleted
+// without locking everyone out of the admin API. It must run in the same
+// transaction as the change: counting locks the admin rows, so two changes
+// racing each other cannot both see the other admin.
+func requireAnotherAdmin(ctx context.Context, tx repository.Repository, action string) error {
+	admins, err := tx.Users().CountEnabledByRole(ctx, models.RoleAdmin)
+	if err != nil {
+		return fmt.Errorf("failed to count admins: %w", err)
+	}
+	if admins <= 1 {
+		return fmt.Errorf("%w: cannot %s the last admin", ErrConflict, action)
+	}
+	return nil
+}
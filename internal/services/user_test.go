@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+	"gorm.io/gorm"
+)
+
+// fakeUserRepository keeps users and the owners of workspaces in memory for
+// the account management tests. Nobody is assigned any tasks.
+type fakeUserRepository struct {
+	repository.Repository
+	users  map[uint]*models.User
+	owners map[uint]uint // Workspace ID to owner ID, archived ones included
+}
+
+func (r *fakeUserRepository) Transaction(ctx context.Context, fn func(tx repository.Repository) error) error {
+	return fn(r)
+}
+
+func (r *fakeUserRepository) Users() repository.UserRepository {
+	return fakeUsers{r: r}
+}
+
+func (r *fakeUserRepository) AuditLogs() repository.AuditLogRepository {
+	return fakeAuditLogs{}
+}
+
+func (r *fakeUserRepository) RefreshTokens() repository.RefreshTokenRepository {
+	return fakeRefreshTokens{}
+}
+
+func (r *fakeUserRepository) Workspaces() repository.WorkspaceRepository {
+	return fakeOwnedWorkspaces{r: r}
+}
+
+func (r *fakeUserRepository) WorkspaceMembers() repository.WorkspaceMemberRepository {
+	return fakeNoMembers{}
+}
+
+func (r *fakeUserRepository) Tasks() repository.TaskRepository {
+	return fakeNoTasks{}
+}
+
+type fakeUsers struct {
+	repository.UserRepository
+	r *fakeUserRepository
+}
+
+func (f fakeUsers) GetByID(ctx context.Context, id uint) (*models.User, error) {
+	if user, ok := f.r.users[id]; ok {
+		clone := *user
+		return &clone, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f fakeUsers) Update(ctx context.Context, user *models.User) error {
+	clone := *user
+	f.r.users[user.ID] = &clone
+	return nil
+}
+
+func (f fakeUsers) CountEnabledByRole(ctx context.Context, role models.UserRole) (int64, error) {
+	var count int64
+	for _, user := range f.r.users {
+		if user.Role == role && !user.IsDisabled() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+type fakeOwnedWorkspaces struct {
+	repository.WorkspaceRepository
+	r *fakeUserRepository
+}
+
+func (f fakeOwnedWorkspaces) ListOwnedByUserID(ctx context.Context, userID uint) ([]models.Workspace, error) {
+	var workspaces []models.Workspace
+	for id, ownerID := range f.r.owners {
+		if ownerID == userID {
+			workspaces = append(workspaces, models.Workspace{ID: id, OwnerID: ownerID})
+		}
+	}
+	return workspaces, nil
+}
+
+type fakeNoMembers struct {
+	repository.WorkspaceMemberRepository
+}
+
+func (fakeNoMembers) DeleteByUserID(ctx context.Context, userID uint) error {
+	return nil
+}
+
+type fakeNoTasks struct {
+	repository.TaskRepository
+}
+
+func (fakeNoTasks) ListByAssigneeID(ctx context.Context, userID uint) ([]models.Task, error) {
+	return nil, nil
+}
+
+type fakeAuditLogs struct {
+	repository.AuditLogRepository
+}
+
+func (fakeAuditLogs) Create(ctx context.Context, entry *models.AuditLog) error {
+	return nil
+}
+
+type fakeRefreshTokens struct {
+	repository.RefreshTokenRepository
+}
+
+func (fakeRefreshTokens) RevokeAllForUser(ctx context.Context, userID uint) error {
+	return nil
+}
+
+func TestLastEnabledAdminIsKept(t *testing.T) {
+	ctx := context.Background()
+	disabledAt := time.Now()
+	newRepo := func() *fakeUserRepository {
+		return &fakeUserRepository{users: map[uint]*models.User{
+			1: {ID: 1, Username: "a", Role: models.RoleAdmin},
+			2: {ID: 2, Username: "b", Role: models.RoleAdmin},
+			3: {ID: 3, Username: "c", Role: models.RoleAdmin, DisabledAt: &disabledAt},
+		}}
+	}
+	admin := Actor{UserID: 1, Role: models.RoleAdmin}
+
+	t.Run("demote one of two", func(t *testing.T) {
+		users := NewUserService(newRepo())
+		if _, err := users.ChangeRole(ctx, admin, 2, models.RoleDev); err != nil {
+			t.Fatalf("got error %v, want none", err)
+		}
+	})
+
+	t.Run("demote after disabling the other", func(t *testing.T) {
+		repo := newRepo()
+		users := NewUserService(repo)
+		if _, err := users.SetDisabled(ctx, admin, 2, true); err != nil {
+			t.Fatalf("disabling the other admin: %v", err)
+		}
+		if _, err := users.ChangeRole(ctx, admin, 1, models.RoleDev); !errors.Is(err, ErrConflict) {
+			t.Fatalf("got error %v, want %v", err, ErrConflict)
+		}
+		if repo.users[1].Role != models.RoleAdmin {
+			t.Errorf("last admin was demoted to %s", repo.users[1].Role)
+		}
+	})
+
+	t.Run("disable the last enabled admin", func(t *testing.T) {
+		repo := newRepo()
+		repo.users[1].DisabledAt = &disabledAt
+		users := NewUserService(repo)
+		if _, err := users.SetDisabled(ctx, admin, 2, true); !errors.Is(err, ErrConflict) {
+			t.Fatalf("got error %v, want %v", err, ErrConflict)
+		}
+		if repo.users[2].IsDisabled() {
+			t.Error("last admin was disabled")
+		}
+	})
+
+	t.Run("delete the last enabled admin", func(t *testing.T) {
+		repo := newRepo()
+		repo.users[1].DisabledAt = &disabledAt
+		users := NewUserService(repo)
+		if err := users.DeleteUser(ctx, Actor{UserID: 9, Role: models.RoleAdmin}, 2, nil); !errors.Is(err, ErrConflict) {
+			t.Fatalf("got error %v, want %v", err, ErrConflict)
+		}
+		if repo.users[2].IsDisabled() {
+			t.Error("last admin was deleted")
+		}
+	})
+
+	t.Run("delete one of two", func(t *testing.T) {
+		repo := newRepo()
+		users := NewUserService(repo)
+		if err := users.DeleteUser(ctx, admin, 2, nil); err != nil {
+			t.Fatalf("got error %v, want none", err)
+		}
+		if !repo.users[2].IsDisabled() {
+			t.Error("deleted admin is still enabled")
+		}
+	})
+
+	t.Run("demote a disabled admin", func(t *testing.T) {
+		repo := newRepo()
+		delete(repo.users, 2)
+		users := NewUserService(repo)
+		if _, err := users.ChangeRole(ctx, admin, 3, models.RoleDev); err != nil {
+			t.Fatalf("got error %v, want none", err)
+		}
+	})
+}
+
+func TestDeleteUserOwningArchivedWorkspace(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeUserRepository{
+		users: map[uint]*models.User{
+			1: {ID: 1, Username: "admin", Role: models.RoleAdmin},
+			2: {ID: 2, Username: "owner", Role: models.RoleDev},
+		},
+		// Workspace 5 is archived; ListByUserID would no longer return it.
+		owners: map[uint]uint{5: 2},
+	}
+
+	err := NewUserService(repo).DeleteUser(ctx, Actor{UserID: 1, Role: models.RoleAdmin}, 2, nil)
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("got error %v, want %v", err, ErrConflict)
+	}
+	if repo.users[2].Username != "owner" {
+		t.Errorf("user was anonymized to %q", repo.users[2].Username)
+	}
+}
@@ -0,0 +1,458 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+)
+
+const (
+	maxWebhookURLLength    = 2048
+	minWebhookSecretLength = 16
+	maxWebhookSecretLength = 255
+
+	// webhookMaxAttempts is how often a delivery is tried before it is marked
+	// failed. Retries back off exponentially from webhookRetryBase, so the
+	// last one happens about four hours after the first attempt.
+	webhookMaxAttempts = 10
+	webhookRetryBase   = 30 * time.Second
+	webhookTimeout     = 10 * time.Second
+	// webhookBatch is how many deliveries the dispatcher claims per query.
+	webhookBatch = 20
+	// webhookLease is how long claimed deliveries are hidden from other
+	// dispatchers; it must outlast sending a whole batch.
+	webhookLease = 5 * time.Minute
+	// maxWebhookResponseBody is how much of a receiver's response is kept in
+	// the delivery log.
+	maxWebhookResponseBody = 1024
+)
+
+type WebhookService struct {
+	repo repository.Repository
+}
+
+func NewWebhookService(repo repository.Repository) *WebhookService {
+	return &WebhookService{repo: repo}
+}
+
+// WebhookInput holds a webhook's settings. On update, empty fields and a nil
+// Events or Active keep the current values.
+type WebhookInput struct {
+	URL    string
+	Secret string
+	Events []string
+	Active *bool
+}
+
+// WebhookPayload is the JSON body posted to webhooks.
+type WebhookPayload struct {
+	Event       string      `json:"event"`
+	WorkspaceID uint        `json:"workspace_id"`
+	OccurredAt  time.Time   `json:"occurred_at"`
+	Data        interface{} `json:"data"`
+}
+
+// WebhookDeliveryPage is a single page of a webhook's deliveries.
+type WebhookDeliveryPage struct {
+	Items    []models.WebhookDelivery `json:"items"`
+	Total    int64                    `json:"total"`
+	Page     int                      `json:"page"`
+	PageSize int                      `json:"page_size"`
+}
+
+func (s *WebhookService) ListWebhooks(ctx context.Context, actor Actor, workspaceID uint) ([]models.Webhook, error) {
+	if _, err := loadWorkspace(ctx, s.repo, actor, workspaceID, models.WorkspaceRoleManager); err != nil {
+		return nil, err
+	}
+	return s.repo.Webhooks().ListByWorkspaceID(ctx, workspaceID)
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, actor Actor, workspaceID uint, input WebhookInput) (*models.Webhook, error) {
+	if _, err := loadWorkspace(ctx, s.repo, actor, workspaceID, models.WorkspaceRoleManager); err != nil {
+		return nil, err
+	}
+	if input.URL == "" || input.Secret == "" || len(input.Events) == 0 {
+		return nil, fmt.Errorf("%w: url, secret and events are required", ErrInvalidInput)
+	}
+
+	webhook := &models.Webhook{WorkspaceID: workspaceID, Active: true, CreatedByID: actor.UserID}
+	if err := applyWebhookInput(webhook, input); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Webhooks().Create(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+func (s *WebhookService) UpdateWebhook(ctx context.Context, actor Actor, id uint, input WebhookInput) (*models.Webhook, error) {
+	webhook, err := s.loadWebhook(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyWebhookInput(webhook, input); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Webhooks().Update(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook removes a webhook and its delivery log. Pending deliveries are
+// dropped.
+func (s *WebhookService) DeleteWebhook(ctx context.Context, actor Actor, id uint) error {
+	webhook, err := s.loadWebhook(ctx, actor, id)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if err := tx.Webhooks().Delete(ctx, webhook.ID); err != nil {
+			return fmt.Errorf("failed to delete webhook: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, actor Actor, webhookID uint, page, pageSize int) (*WebhookDeliveryPage, error) {
+	if _, err := s.loadWebhook(ctx, actor, webhookID); err != nil {
+		return nil, err
+	}
+	page, pageSize = normalizePage(page, pageSize)
+
+	deliveries, total, err := s.repo.Webhooks().ListDeliveries(ctx, webhookID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	return &WebhookDeliveryPage{
+		Items:    deliveries,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+	}, nil
+}
+
+// Redeliver queues a new delivery of an earlier delivery's payload, to be
+// sent right away. The webhook's current URL and secret are used.
+func (s *WebhookService) Redeliver(ctx context.Context, actor Actor, webhookID, deliveryID uint) (*models.WebhookDelivery, error) {
+	webhook, err := s.loadWebhook(ctx, actor, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	original, err := s.repo.Webhooks().GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, lookupError("delivery", err)
+	}
+	if original.WebhookID != webhook.ID {
+		return nil, fmt.Errorf("delivery %w", ErrNotFound)
+	}
+
+	now := time.Now()
+	delivery := &models.WebhookDelivery{
+		WebhookID:     webhook.ID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: &now,
+	}
+	if err := s.repo.Webhooks().CreateDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to queue delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// loadWebhook fetches a webhook of a workspace the actor manages.
+func (s *WebhookService) loadWebhook(ctx context.Context, actor Actor, id uint) (*models.Webhook, error) {
+	webhook, err := s.repo.Webhooks().GetByID(ctx, id)
+	if err != nil {
+		return nil, lookupError("webhook", err)
+	}
+	if err := authorizeWorkspace(ctx, s.repo, actor, webhook.WorkspaceID, models.WorkspaceRoleManager, "webhook"); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// applyWebhookInput validates input and copies its non-empty fields to
+// webhook.
+func applyWebhookInput(webhook *models.Webhook, input WebhookInput) error {
+	if input.URL != "" {
+		u, err := url.Parse(input.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidInput)
+		}
+		if len(input.URL) > maxWebhookURLLength {
+			return fmt.Errorf("%w: url must be at most %d characters", ErrInvalidInput, maxWebhookURLLength)
+		}
+		webhook.URL = input.URL
+	}
+	if input.Secret != "" {
+		n := utf8.RuneCountInString(input.Secret)
+		if n < minWebhookSecretLength || n > maxWebhookSecretLength {
+			return fmt.Errorf("%w: secret must be %d to %d characters", ErrInvalidInput, minWebhookSecretLength, maxWebhookSecretLength)
+		}
+		webhook.Secret = input.Secret
+	}
+	if input.Events != nil {
+		if len(input.Events) == 0 {
+			return fmt.Errorf("%w: events must not be empty", ErrInvalidInput)
+		}
+		events := []string{}
+		for _, event := range input.Events {
+			if !containsString(models.WebhookEvents, event) {
+				return &ValidationError{Field: "events", Value: event, Allowed: models.WebhookEvents}
+			}
+			if !containsString(events, event) {
+				events = append(events, event)
+			}
+		}
+		webhook.Events = events
+	}
+	if input.Active != nil {
+		webhook.Active = *input.Active
+	}
+	return nil
+}
+
+// enqueueWebhooks queues a delivery of an event for every active webhook of
+// the workspace subscribed to it. It runs after the change has been committed
+// (see committedContext).
+func enqueueWebhooks(ctx context.Context, repo repository.Repository, workspaceID uint, event string, data interface{}) {
+	ctx = committedContext(ctx)
+
+	webhooks, err := repo.Webhooks().ListByWorkspaceID(ctx, workspaceID)
+	if err != nil {
+		log.Printf("Webhooks: failed to load webhooks of workspace %d: %v", workspaceID, err)
+		return
+	}
+	var payload []byte
+	for i := range webhooks {
+		if !webhooks[i].Subscribes(event) {
+			continue
+		}
+		if payload == nil {
+			payload, err = json.Marshal(WebhookPayload{
+				Event:       event,
+				WorkspaceID: workspaceID,
+				OccurredAt:  time.Now().UTC(),
+				Data:        data,
+			})
+			if err != nil {
+				log.Printf("Webhooks: failed to encode %s payload: %v", event, err)
+				return
+			}
+		}
+		now := time.Now()
+		delivery := &models.WebhookDelivery{
+			WebhookID:     webhooks[i].ID,
+			Event:         event,
+			Payload:       string(payload),
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: &now,
+		}
+		if err := repo.Webhooks().CreateDelivery(ctx, delivery); err != nil {
+			log.Printf("Webhooks: failed to queue %s for webhook %d: %v", event, webhooks[i].ID, err)
+		}
+	}
+}
+
+// SignWebhookPayload returns the value of the X-Webhook-Signature header: the
+// hex HMAC-SHA256, keyed with the webhook's secret, of the X-Webhook-Timestamp
+// value, a dot and the request body.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewWebhookClient returns the HTTP client used to send webhooks. Redirects
+// are not followed, and connections to loopback, private, link-local and
+// other non-public addresses are refused. The address is checked when the
+// connection is made, after DNS resolution, so a hostname cannot be rebound
+// to an internal address once the webhook has been saved.
+func NewWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: refusePrivateAddress,
+	}
+	return &http.Client{
+		Timeout: webhookTimeout,
+		// No proxy, so that the dialer sees the receiver's own address.
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: webhookTimeout,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// nonPublicPrefixes are reserved ranges that netip does not classify as
+// private, loopback or link-local but that must not be reached either.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "This network"
+	netip.MustParsePrefix("100.64.0.0/10"), // Carrier-grade NAT, some cloud metadata services
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // Benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),   // Reserved, including broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, which can map onto internal IPv4 addresses
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+}
+
+// refusePrivateAddress is a net.Dialer Control hook that fails connections to
+// addresses that are not publicly routable.
+func refusePrivateAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("webhook address %q: %w", address, err)
+	}
+	ip := addrPort.Addr().Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("webhook address %s is not public", ip)
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(ip) {
+			return fmt.Errorf("webhook address %s is not public", ip)
+		}
+	}
+	return nil
+}
+
+// StartWebhookDispatcher launches a background goroutine that sends due
+// webhook deliveries every interval using client, retrying failed ones with
+// exponential backoff.
+func StartWebhookDispatcher(repo repository.Repository, client *http.Client, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			<-ticker.C
+			if _, err := dispatchWebhooks(context.Background(), repo, client); err != nil {
+				log.Printf("Webhook dispatcher: %v", err)
+			}
+		}
+	}()
+}
+
+// dispatchWebhooks sends every delivery that is due and returns how many were
+// attempted.
+func dispatchWebhooks(ctx context.Context, repo repository.Repository, client *http.Client) (int, error) {
+	attempted := 0
+	webhooks := map[uint]*models.Webhook{}
+	for {
+		deliveries, err := repo.Webhooks().ClaimDueDeliveries(ctx, time.Now(), webhookLease, webhookBatch)
+		if err != nil {
+			return attempted, fmt.Errorf("failed to claim deliveries: %w", err)
+		}
+		for i := range deliveries {
+			delivery := &deliveries[i]
+			webhook, ok := webhooks[delivery.WebhookID]
+			if !ok {
+				if webhook, err = repo.Webhooks().GetByID(ctx, delivery.WebhookID); err != nil {
+					return attempted, fmt.Errorf("failed to load webhook %d: %w", delivery.WebhookID, err)
+				}
+				webhooks[delivery.WebhookID] = webhook
+			}
+
+			deliverWebhook(ctx, client, webhook, delivery, time.Now())
+			if err := repo.Webhooks().UpdateDelivery(ctx, delivery); err != nil {
+				return attempted, fmt.Errorf("failed to update delivery %d: %w", delivery.ID, err)
+			}
+			attempted++
+		}
+		if len(deliveries) < webhookBatch {
+			return attempted, nil
+		}
+	}
+}
+
+// deliverWebhook makes one attempt at sending a delivery and records the
+// outcome on it: succeeded on a 2xx response, otherwise pending with the next
+// retry scheduled, or failed once the attempts are used up.
+func deliverWebhook(ctx context.Context, client *http.Client, webhook *models.Webhook, delivery *models.WebhookDelivery, now time.Time) {
+	if !webhook.Active {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+		delivery.Error = "webhook is inactive"
+		return
+	}
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = 0
+	delivery.ResponseBody = ""
+	delivery.Error = ""
+
+	status, body, err := sendWebhook(ctx, client, webhook, delivery, now)
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = body
+	switch {
+	case err != nil:
+		delivery.Error = err.Error()
+	case status < 200 || status > 299:
+		delivery.Error = fmt.Sprintf("unexpected status %d", status)
+	default:
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.NextAttemptAt = nil
+		return
+	}
+
+	if delivery.Attempts >= webhookMaxAttempts {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+		return
+	}
+	next := now.Add(webhookRetryBase << (delivery.Attempts - 1))
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.NextAttemptAt = &next
+}
+
+// sendWebhook posts a delivery's payload and returns the response status and
+// the start of the response body.
+func sendWebhook(ctx context.Context, client *http.Client, webhook *models.Webhook, delivery *models.WebhookDelivery, now time.Time) (int, string, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Collaborative-Taskmanager-Webhook/1.0")
+	req.Header.Set("X-Webhook-Event", delivery.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", SignWebhookPayload(webhook.Secret, timestamp, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseBody))
+	// Postgres text columns reject invalid UTF-8 and NUL bytes.
+	return resp.StatusCode, strings.ReplaceAll(strings.ToValidUTF8(string(excerpt), ""), "\x00", ""), nil
+}
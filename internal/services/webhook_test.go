@@ -0,0 +1,382 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+	"gorm.io/gorm"
+)
+
+func TestRefusePrivateAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		refused bool
+	}{
+		{"93.184.215.14:443", false},
+		{"[2606:2800:21f:cb07:6820:80da:af6b:8b2c]:443", false},
+		{"127.0.0.1:80", true},
+		{"[::1]:80", true},
+		{"10.1.2.3:80", true},
+		{"172.16.0.1:80", true},
+		{"192.168.1.1:80", true},
+		{"[fd00::1]:80", true},
+		{"169.254.169.254:80", true},
+		{"[fe80::1]:80", true},
+		{"0.0.0.0:80", true},
+		{"[::ffff:127.0.0.1]:80", true},
+		{"[::ffff:10.0.0.1]:80", true},
+		{"0.1.2.3:80", true},
+		{"100.64.0.1:80", true},
+		{"100.100.100.200:80", true},
+		{"100.127.255.254:80", true},
+		{"100.128.0.1:80", false},
+		{"192.0.0.170:80", true},
+		{"198.18.0.1:80", true},
+		{"198.19.255.254:80", true},
+		{"198.20.0.1:80", false},
+		{"255.255.255.255:80", true},
+		{"[64:ff9b::a9fe:a9fe]:80", true},
+		{"[64:ff9b::7f00:1]:80", true},
+		{"[64:ff9b:1::a00:1]:80", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := refusePrivateAddress("tcp", tt.address, nil)
+			if refused := err != nil; refused != tt.refused {
+				t.Fatalf("refused = %v (%v), want %v", refused, err, tt.refused)
+			}
+		})
+	}
+}
+
+func TestWebhookClientRefusesLoopback(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	resp, err := NewWebhookClient().Post(server.URL, "application/json", nil)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("request to a loopback receiver succeeded")
+	}
+	if called {
+		t.Fatal("loopback receiver was reached")
+	}
+}
+
+// fakeWebhookRepository adds webhooks and their deliveries to the two-tenant
+// fixture of the access tests.
+type fakeWebhookRepository struct {
+	*fakeRepository
+	webhooks   map[uint]*models.Webhook
+	deliveries map[uint]*models.WebhookDelivery
+	nextID     uint
+}
+
+func (r *fakeWebhookRepository) Webhooks() repository.WebhookRepository {
+	return fakeWebhooks{r: r}
+}
+
+type fakeWebhooks struct {
+	repository.WebhookRepository
+	r *fakeWebhookRepository
+}
+
+func (f fakeWebhooks) GetByID(ctx context.Context, id uint) (*models.Webhook, error) {
+	if webhook, ok := f.r.webhooks[id]; ok {
+		clone := *webhook
+		return &clone, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f fakeWebhooks) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	f.r.nextID++
+	delivery.ID = f.r.nextID
+	clone := *delivery
+	f.r.deliveries[delivery.ID] = &clone
+	return nil
+}
+
+func (f fakeWebhooks) GetDelivery(ctx context.Context, id uint) (*models.WebhookDelivery, error) {
+	if delivery, ok := f.r.deliveries[id]; ok {
+		clone := *delivery
+		return &clone, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f fakeWebhooks) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	clone := *delivery
+	f.r.deliveries[delivery.ID] = &clone
+	return nil
+}
+
+func (f fakeWebhooks) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var claimed []models.WebhookDelivery
+	for id := uint(1); id <= f.r.nextID && len(claimed) < limit; id++ {
+		delivery, ok := f.r.deliveries[id]
+		if !ok || delivery.Status != models.WebhookDeliveryPending || delivery.NextAttemptAt == nil || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		leased := now.Add(lease)
+		delivery.NextAttemptAt = &leased
+		claimed = append(claimed, *delivery)
+	}
+	return claimed, nil
+}
+
+const testWebhookSecret = "whsec-0123456789abcdef"
+
+// receivedWebhook is a request seen by a webhookReceiver.
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+// webhookReceiver is a local endpoint that records the webhooks posted to it
+// and answers with status and body.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	status   int
+	body     string
+	received []receivedWebhook
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.received = append(r.received, receivedWebhook{header: req.Header.Clone(), body: body})
+	w.WriteHeader(r.status)
+	io.WriteString(w, r.body)
+}
+
+// newWebhookFixture returns the two-tenant fixture with an active webhook of
+// workspace 1 that posts to a local receiver, and one delivery for it that is
+// due now.
+func newWebhookFixture(t *testing.T, status int, body string) (*fakeWebhookRepository, *webhookReceiver, *http.Client) {
+	t.Helper()
+	receiver := &webhookReceiver{status: status, body: body}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+
+	now := time.Now()
+	repo := &fakeWebhookRepository{
+		fakeRepository: newTenantFixture(),
+		webhooks: map[uint]*models.Webhook{
+			1: {ID: 1, WorkspaceID: 1, URL: server.URL + "/hook", Secret: testWebhookSecret, Events: []string{models.EventTaskCreated}, Active: true},
+			2: {ID: 2, WorkspaceID: 2, URL: server.URL + "/other", Secret: testWebhookSecret, Events: []string{models.EventTaskCreated}, Active: true},
+		},
+		deliveries: map[uint]*models.WebhookDelivery{},
+	}
+	fakeWebhooks{r: repo}.CreateDelivery(context.Background(), &models.WebhookDelivery{
+		WebhookID:     1,
+		Event:         models.EventTaskCreated,
+		Payload:       `{"event":"task.created","workspace_id":1}`,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: &now,
+	})
+	// The SSRF guard of NewWebhookClient would refuse the local receiver.
+	return repo, receiver, server.Client()
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	got := SignWebhookPayload(testWebhookSecret, 1767225600, []byte(`{"event":"task.created"}`))
+	want := "sha256=4ce71f1241d0958e73507f7fb1611a29936b10b7181635306e55abe081fc9da1"
+	if got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestDispatchWebhooksDelivers(t *testing.T) {
+	repo, receiver, client := newWebhookFixture(t, http.StatusOK, "thanks")
+
+	attempted, err := dispatchWebhooks(context.Background(), repo, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempted != 1 || len(receiver.received) != 1 {
+		t.Fatalf("attempted %d, received %d, want 1 and 1", attempted, len(receiver.received))
+	}
+
+	request := receiver.received[0]
+	if string(request.body) != repo.deliveries[1].Payload {
+		t.Errorf("body %s, want the payload", request.body)
+	}
+	for name, want := range map[string]string{
+		"Content-Type":       "application/json",
+		"X-Webhook-Event":    models.EventTaskCreated,
+		"X-Webhook-Delivery": "1",
+	} {
+		if got := request.header.Get(name); got != want {
+			t.Errorf("%s: %q, want %q", name, got, want)
+		}
+	}
+	timestamp, err := strconv.ParseInt(request.header.Get("X-Webhook-Timestamp"), 10, 64)
+	if err != nil {
+		t.Fatalf("X-Webhook-Timestamp: %v", err)
+	}
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	fmt.Fprintf(mac, "%d.%s", timestamp, request.body)
+	if got, want := request.header.Get("X-Webhook-Signature"), "sha256="+hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Errorf("X-Webhook-Signature: %q, want %q", got, want)
+	}
+
+	delivery := repo.deliveries[1]
+	if delivery.Status != models.WebhookDeliverySucceeded || delivery.Attempts != 1 || delivery.NextAttemptAt != nil {
+		t.Errorf("delivery %s after %d attempts, next at %v", delivery.Status, delivery.Attempts, delivery.NextAttemptAt)
+	}
+	if delivery.ResponseStatus != http.StatusOK || delivery.ResponseBody != "thanks" || delivery.Error != "" {
+		t.Errorf("recorded response %d %q, error %q", delivery.ResponseStatus, delivery.ResponseBody, delivery.Error)
+	}
+
+	// Nothing is due any more.
+	if attempted, err := dispatchWebhooks(context.Background(), repo, client); err != nil || attempted != 0 {
+		t.Errorf("second run attempted %d (%v), want 0", attempted, err)
+	}
+}
+
+func TestDispatchWebhooksSchedulesRetry(t *testing.T) {
+	repo, _, client := newWebhookFixture(t, http.StatusServiceUnavailable, "busy")
+
+	before := time.Now()
+	attempted, err := dispatchWebhooks(context.Background(), repo, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The retry is not due yet, so it is not sent again in the same run.
+	if attempted != 1 {
+		t.Fatalf("attempted %d, want 1", attempted)
+	}
+
+	delivery := repo.deliveries[1]
+	if delivery.Status != models.WebhookDeliveryPending || delivery.Attempts != 1 {
+		t.Fatalf("delivery %s after %d attempts, want pending after 1", delivery.Status, delivery.Attempts)
+	}
+	if delivery.ResponseStatus != http.StatusServiceUnavailable || delivery.ResponseBody != "busy" || delivery.Error != "unexpected status 503" {
+		t.Errorf("recorded response %d %q, error %q", delivery.ResponseStatus, delivery.ResponseBody, delivery.Error)
+	}
+	if delivery.NextAttemptAt == nil || delivery.NextAttemptAt.Before(before.Add(webhookRetryBase)) {
+		t.Errorf("next attempt at %v, want at least %v from now", delivery.NextAttemptAt, webhookRetryBase)
+	}
+}
+
+func TestDeliverWebhookBacksOffUntilFailed(t *testing.T) {
+	repo, receiver, client := newWebhookFixture(t, http.StatusInternalServerError, "")
+	webhook := repo.webhooks[1]
+	delivery := repo.deliveries[1]
+	now := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	wait := webhookRetryBase
+	for attempt := 1; attempt < webhookMaxAttempts; attempt++ {
+		deliverWebhook(context.Background(), client, webhook, delivery, now)
+		if delivery.Status != models.WebhookDeliveryPending || delivery.Attempts != attempt {
+			t.Fatalf("attempt %d: delivery %s after %d attempts", attempt, delivery.Status, delivery.Attempts)
+		}
+		if want := now.Add(wait); delivery.NextAttemptAt == nil || !delivery.NextAttemptAt.Equal(want) {
+			t.Fatalf("attempt %d: next attempt at %v, want %v", attempt, delivery.NextAttemptAt, want)
+		}
+		now = *delivery.NextAttemptAt
+		wait *= 2
+	}
+
+	deliverWebhook(context.Background(), client, webhook, delivery, now)
+	if delivery.Status != models.WebhookDeliveryFailed || delivery.NextAttemptAt != nil {
+		t.Fatalf("after %d attempts: delivery %s, next at %v, want failed", delivery.Attempts, delivery.Status, delivery.NextAttemptAt)
+	}
+	if delivery.Attempts != webhookMaxAttempts || len(receiver.received) != webhookMaxAttempts {
+		t.Errorf("%d attempts, %d received, want %d", delivery.Attempts, len(receiver.received), webhookMaxAttempts)
+	}
+}
+
+func TestDeliverWebhookWithoutResponse(t *testing.T) {
+	repo, _, client := newWebhookFixture(t, http.StatusOK, "")
+	webhook := repo.webhooks[1]
+	webhook.URL = "http://127.0.0.1:0/hook"
+	delivery := repo.deliveries[1]
+
+	deliverWebhook(context.Background(), client, webhook, delivery, time.Now())
+	if delivery.Status != models.WebhookDeliveryPending || delivery.ResponseStatus != 0 || delivery.Error == "" {
+		t.Errorf("delivery %s, status %d, error %q, want pending with an error", delivery.Status, delivery.ResponseStatus, delivery.Error)
+	}
+}
+
+func TestDeliverWebhookInactive(t *testing.T) {
+	repo, receiver, client := newWebhookFixture(t, http.StatusOK, "")
+	webhook := repo.webhooks[1]
+	webhook.Active = false
+	delivery := repo.deliveries[1]
+
+	deliverWebhook(context.Background(), client, webhook, delivery, time.Now())
+	if delivery.Status != models.WebhookDeliveryFailed || delivery.Attempts != 0 || len(receiver.received) != 0 {
+		t.Errorf("delivery %s after %d attempts, %d received, want failed without sending", delivery.Status, delivery.Attempts, len(receiver.received))
+	}
+}
+
+func TestRedeliver(t *testing.T) {
+	ctx := context.Background()
+	repo, receiver, client := newWebhookFixture(t, http.StatusInternalServerError, "")
+	webhooks := NewWebhookService(repo)
+
+	// Let the first delivery fail for good.
+	delivery := repo.deliveries[1]
+	delivery.Status = models.WebhookDeliveryFailed
+	delivery.Attempts = webhookMaxAttempts
+	delivery.NextAttemptAt = nil
+
+	tests := []struct {
+		name      string
+		actor     Actor
+		webhookID uint
+		want      error
+	}{
+		{"non-member", dev(bobID), 1, ErrNotFound},
+		{"viewer", dev(viewerID), 1, ErrForbidden},
+		{"delivery of another webhook", dev(bobID), 2, ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := webhooks.Redeliver(ctx, tt.actor, tt.webhookID, 1); !errors.Is(err, tt.want) {
+				t.Fatalf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	redelivery, err := webhooks.Redeliver(ctx, dev(aliceID), 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redelivery.ID == 1 || redelivery.Payload != delivery.Payload || redelivery.Status != models.WebhookDeliveryPending || redelivery.Attempts != 0 {
+		t.Fatalf("redelivery %+v", redelivery)
+	}
+
+	receiver.status = http.StatusOK
+	if attempted, err := dispatchWebhooks(ctx, repo, client); err != nil || attempted != 1 {
+		t.Fatalf("dispatch attempted %d (%v), want 1", attempted, err)
+	}
+	if got := repo.deliveries[redelivery.ID].Status; got != models.WebhookDeliverySucceeded {
+		t.Errorf("redelivery %s, want succeeded", got)
+	}
+	if got := receiver.received[0].header.Get("X-Webhook-Delivery"); got != strconv.FormatUint(uint64(redelivery.ID), 10) {
+		t.Errorf("X-Webhook-Delivery %s, want the new delivery ID %d", got, redelivery.ID)
+	}
+	if repo.deliveries[1].Status != models.WebhookDeliveryFailed {
+		t.Errorf("original delivery %s, want it left failed", repo.deliveries[1].Status)
+	}
+}
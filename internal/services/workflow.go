@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+)
+
+// statusNamePattern limits custom status names to what fits the tasks.status
+// column and reads well in URLs and filters.
+var statusNamePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{0,19}$`)
+
+type WorkflowService struct {
+	repo repository.Repository
+}
+
+func NewWorkflowService(repo repository.Repository) *WorkflowService {
+	return &WorkflowService{repo: repo}
+}
+
+// WorkflowInput is a complete workflow definition for a project.
+type WorkflowInput struct {
+	Statuses    []WorkflowStatusInput
+	Transitions []WorkflowTransitionInput
+}
+
+type WorkflowStatusInput struct {
+	Name    models.TaskStatus
+	Initial bool
+	Done    bool
+}
+
+type WorkflowTransitionInput struct {
+	From    models.TaskStatus
+	To      models.TaskStatus
+	MinRole models.WorkspaceRole
+}
+
+func (s *WorkflowService) GetWorkflow(ctx context.Context, actor Actor, projectID uint) (*models.Workflow, error) {
+	if _, err := loadProject(ctx, s.repo, actor, projectID, models.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+	return projectWorkflow(ctx, s.repo, projectID)
+}
+
+// UpdateWorkflow replaces the project's workflow. Every status currently held
+// by one of the project's tasks must remain part of it.
+func (s *WorkflowService) UpdateWorkflow(ctx context.Context, actor Actor, projectID uint, input WorkflowInput) (*models.Workflow, error) {
+	if _, err := loadProject(ctx, s.repo, actor, projectID, models.WorkspaceRoleManager); err != nil {
+		return nil, err
+	}
+
+	workflow, err := buildWorkflow(projectID, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.replace(ctx, projectID, workflow); err != nil {
+		return nil, err
+	}
+
+	return projectWorkflow(ctx, s.repo, projectID)
+}
+
+// ResetWorkflow drops the project's custom workflow so it uses the default one
+// again.
+func (s *WorkflowService) ResetWorkflow(ctx context.Context, actor Actor, projectID uint) (*models.Workflow, error) {
+	if _, err := loadProject(ctx, s.repo, actor, projectID, models.WorkspaceRoleManager); err != nil {
+		return nil, err
+	}
+
+	if err := s.replace(ctx, projectID, nil); err != nil {
+		return nil, err
+	}
+
+	return models.DefaultWorkflow(projectID), nil
+}
+
+// replace stores workflow as the project's definition, or drops the custom
+// definition when workflow is nil, after checking that no task would be left
+// in a status the resulting workflow does not define.
+func (s *WorkflowService) replace(ctx context.Context, projectID uint, workflow *models.Workflow) error {
+	effective := workflow
+	if effective == nil {
+		effective = models.DefaultWorkflow(projectID)
+		workflow = &models.Workflow{ProjectID: projectID}
+	}
+
+	return s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		inUse, err := tx.Tasks().ListStatusesInUse(ctx, projectID)
+		if err != nil {
+			return fmt.Errorf("failed to load task statuses: %w", err)
+		}
+		for _, status := range inUse {
+			if !effective.HasStatus(status) {
+				return fmt.Errorf("%w: tasks in this project still use status %s", ErrConflict, status)
+			}
+		}
+
+		if err := tx.Workflows().Replace(ctx, projectID, workflow.Statuses, workflow.Transitions); err != nil {
+			return fmt.Errorf("failed to save workflow: %w", err)
+		}
+		if err := tx.Tasks().SyncCompletion(ctx, projectID, effective.DoneStatuses(), time.Now()); err != nil {
+			return fmt.Errorf("failed to update task completion: %w", err)
+		}
+		return nil
+	})
+}
+
+// buildWorkflow validates input and turns it into a workflow for the project.
+// Status names are upper-cased; the first status is initial unless another
+// one is marked, and transitions default to the dev role.
+func buildWorkflow(projectID uint, input WorkflowInput) (*models.Workflow, error) {
+	if len(input.Statuses) == 0 {
+		return nil, fmt.Errorf("%w: a workflow needs at least one status", ErrInvalidInput)
+	}
+
+	workflow := &models.Workflow{ProjectID: projectID, Custom: true}
+	initial := -1
+	for i, in := range input.Statuses {
+		name := models.TaskStatus(strings.ToUpper(strings.TrimSpace(string(in.Name))))
+		if !statusNamePattern.MatchString(string(name)) {
+			return nil, fmt.Errorf("%w: status %q must be 1-20 letters, digits or underscores, starting with a letter", ErrInvalidInput, in.Name)
+		}
+		if workflow.HasStatus(name) {
+			return nil, fmt.Errorf("%w: duplicate status %s", ErrInvalidInput, name)
+		}
+		if in.Initial {
+			if initial >= 0 {
+				return nil, fmt.Errorf("%w: only one status can be initial", ErrInvalidInput)
+			}
+			initial = i
+		}
+		workflow.Statuses = append(workflow.Statuses, models.WorkflowStatus{
+			ProjectID: projectID,
+			Name:      name,
+			Position:  i,
+			Done:      in.Done,
+		})
+	}
+	if initial < 0 {
+		initial = 0
+	}
+	workflow.Statuses[initial].Initial = true
+
+	for _, in := range input.Transitions {
+		from := models.TaskStatus(strings.ToUpper(strings.TrimSpace(string(in.From))))
+		to := models.TaskStatus(strings.ToUpper(strings.TrimSpace(string(in.To))))
+		if !workflow.HasStatus(from) || !workflow.HasStatus(to) {
+			return nil, fmt.Errorf("%w: transition %s -> %s uses an undefined status", ErrInvalidInput, in.From, in.To)
+		}
+		if from == to {
+			return nil, fmt.Errorf("%w: transition %s -> %s does not change the status", ErrInvalidInput, from, to)
+		}
+		if _, exists := workflow.Transition(from, to); exists {
+			return nil, fmt.Errorf("%w: duplicate transition %s -> %s", ErrInvalidInput, from, to)
+		}
+
+		minRole := in.MinRole
+		if minRole == "" {
+			minRole = models.WorkspaceRoleDev
+		}
+		if _, ok := workspaceRoleRank[minRole]; !ok {
+			return nil, fmt.Errorf("%w: min_role must be one of viewer, dev, manager, owner", ErrInvalidInput)
+		}
+
+		workflow.Transitions = append(workflow.Transitions, models.WorkflowTransition{
+			ProjectID: projectID,
+			From:      from,
+			To:        to,
+			MinRole:   minRole,
+		})
+	}
+
+	return workflow, nil
+}
+
+// projectWorkflow returns the project's custom workflow, or the default one.
+func projectWorkflow(ctx context.Context, repo repository.Repository, projectID uint) (*models.Workflow, error) {
+	workflow, err := repo.Workflows().Get(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workflow: %w", err)
+	}
+	if workflow == nil {
+		return models.DefaultWorkflow(projectID), nil
+	}
+	return workflow, nil
+}
+
+// checkTransition verifies that a member with role may move a task from one
+// status to another under workflow.
+func checkTransition(workflow *models.Workflow, from, to models.TaskStatus, role models.WorkspaceRole) error {
+	if err := validateStatus(workflow, to); err != nil {
+		return err
+	}
+	transition, ok := workflow.Transition(from, to)
+	if !ok {
+		return fmt.Errorf("%w: transition from %s to %s is not allowed", ErrUnprocessable, from, to)
+	}
+	if workspaceRoleRank[role] < workspaceRoleRank[transition.MinRole] {
+		return fmt.Errorf("%w: transition from %s to %s requires workspace role %s or higher", ErrUnprocessable, from, to, transition.MinRole)
+	}
+	return nil
+}
@@ -2,10 +2,12 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
+	"gorm.io/gorm"
 )
 
 type WorkspaceService struct {
@@ -22,17 +24,198 @@ func (s *WorkspaceService) CreateWorkspace(ctx context.Context, name string, own
 		OwnerID: ownerID,
 	}
 
-	if err := s.repo.Workspaces().Create(ctx, workspace); err != nil {
-		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	err := s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if err := tx.Workspaces().Create(ctx, workspace); err != nil {
+			return fmt.Errorf("failed to create workspace: %w", err)
+		}
+		owner := &models.WorkspaceMember{
+			WorkspaceID: workspace.ID,
+			UserID:      ownerID,
+			Role:        models.WorkspaceRoleOwner,
+		}
+		if err := tx.WorkspaceMembers().Create(ctx, owner); err != nil {
+			return fmt.Errorf("failed to add workspace owner: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return workspace, nil
 }
 
-func (s *WorkspaceService) GetWorkspace(ctx context.Context, id uint) (*models.Workspace, error) {
-	return s.repo.Workspaces().GetByID(ctx, id)
+func (s *WorkspaceService) GetWorkspace(ctx context.Context, actor Actor, id uint) (*models.Workspace, error) {
+	return loadWorkspace(ctx, s.repo, actor, id, models.WorkspaceRoleViewer)
 }
 
 func (s *WorkspaceService) ListUserWorkspaces(ctx context.Context, userID uint) ([]models.Workspace, error) {
 	return s.repo.Workspaces().ListByUserID(ctx, userID)
 }
+
+func (s *WorkspaceService) UpdateWorkspace(ctx context.Context, actor Actor, id uint, name string) (*models.Workspace, error) {
+	workspace, err := loadWorkspace(ctx, s.repo, actor, id, models.WorkspaceRoleManager)
+	if err != nil {
+		return nil, err
+	}
+
+	workspace.Name = name
+	if err := s.repo.Workspaces().Update(ctx, workspace); err != nil {
+		return nil, fmt.Errorf("failed to update workspace: %w", err)
+	}
+
+	return workspace, nil
+}
+
+// ArchiveWorkspace soft-deletes the workspace together with its projects and
+// their tasks. Only the owner may archive a workspace.
+func (s *WorkspaceService) ArchiveWorkspace(ctx context.Context, actor Actor, id uint) error {
+	if _, err := loadWorkspace(ctx, s.repo, actor, id, models.WorkspaceRoleOwner); err != nil {
+		return err
+	}
+
+	at := archiveTimestamp()
+	return s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if err := tx.Tasks().ArchiveByWorkspaceID(ctx, id, at); err != nil {
+			return fmt.Errorf("failed to archive workspace tasks: %w", err)
+		}
+		if err := tx.Projects().ArchiveByWorkspaceID(ctx, id, at); err != nil {
+			return fmt.Errorf("failed to archive workspace projects: %w", err)
+		}
+		if err := tx.Workspaces().Archive(ctx, id, at); err != nil {
+			return fmt.Errorf("failed to archive workspace: %w", err)
+		}
+		return nil
+	})
+}
+
+// RestoreWorkspace brings back an archived workspace along with the projects
+// and tasks that were archived with it. Projects or tasks archived on their
+// own beforehand stay archived.
+func (s *WorkspaceService) RestoreWorkspace(ctx context.Context, actor Actor, id uint) (*models.Workspace, error) {
+	workspace, err := s.repo.Workspaces().GetIncludingArchived(ctx, id)
+	if err != nil {
+		return nil, lookupError("workspace", err)
+	}
+	if err := authorizeWorkspace(ctx, s.repo, actor, workspace.ID, models.WorkspaceRoleOwner, "workspace"); err != nil {
+		return nil, err
+	}
+	if !workspace.DeletedAt.Valid {
+		return nil, fmt.Errorf("%w: workspace is not archived", ErrConflict)
+	}
+
+	at := workspace.DeletedAt.Time
+	err = s.repo.Transaction(ctx, func(tx repository.Repository) error {
+		if err := tx.Workspaces().Restore(ctx, id); err != nil {
+			return fmt.Errorf("failed to restore workspace: %w", err)
+		}
+		if err := tx.Projects().RestoreByWorkspaceID(ctx, id, at); err != nil {
+			return fmt.Errorf("failed to restore workspace projects: %w", err)
+		}
+		if err := tx.Tasks().RestoreByWorkspaceID(ctx, id, at); err != nil {
+			return fmt.Errorf("failed to restore workspace tasks: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.Workspaces().GetByID(ctx, id)
+}
+
+// InviteMember adds the user registered under email to the workspace with the
+// given role. Ownership cannot be granted this way.
+func (s *WorkspaceService) InviteMember(ctx context.Context, actor Actor, workspaceID uint, email string, role models.WorkspaceRole) (*models.WorkspaceMember, error) {
+	if _, err := loadWorkspace(ctx, s.repo, actor, workspaceID, models.WorkspaceRoleManager); err != nil {
+		return nil, err
+	}
+	if err := validateMemberRole(role); err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.Users().GetByEmail(ctx, email)
+	if err != nil {
+		return nil, lookupError("user", err)
+	}
+
+	if _, err := s.repo.WorkspaceMembers().Get(ctx, workspaceID, user.ID); err == nil {
+		return nil, fmt.Errorf("%w: user is already a workspace member", ErrConflict)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load workspace member: %w", err)
+	}
+
+	member := &models.WorkspaceMember{
+		WorkspaceID: workspaceID,
+		UserID:      user.ID,
+		Role:        role,
+	}
+	if err := s.repo.WorkspaceMembers().Create(ctx, member); err != nil {
+		return nil, fmt.Errorf("failed to add workspace member: %w", err)
+	}
+	member.User = *user
+
+	return member, nil
+}
+
+func (s *WorkspaceService) ListMembers(ctx context.Context, actor Actor, workspaceID uint) ([]models.WorkspaceMember, error) {
+	if _, err := loadWorkspace(ctx, s.repo, actor, workspaceID, models.WorkspaceRoleViewer); err != nil {
+		return nil, err
+	}
+	return s.repo.WorkspaceMembers().ListByWorkspaceID(ctx, workspaceID)
+}
+
+// UpdateMemberRole changes a member's workspace role. The owner's role is fixed.
+func (s *WorkspaceService) UpdateMemberRole(ctx context.Context, actor Actor, workspaceID, userID uint, role models.WorkspaceRole) (*models.WorkspaceMember, error) {
+	if _, err := loadWorkspace(ctx, s.repo, actor, workspaceID, models.WorkspaceRoleManager); err != nil {
+		return nil, err
+	}
+	if err := validateMemberRole(role); err != nil {
+		return nil, err
+	}
+
+	member, err := s.repo.WorkspaceMembers().Get(ctx, workspaceID, userID)
+	if err != nil {
+		return nil, lookupError("workspace member", err)
+	}
+	if member.Role == models.WorkspaceRoleOwner {
+		return nil, fmt.Errorf("%w: the workspace owner's role cannot be changed", ErrConflict)
+	}
+
+	member.Role = role
+	if err := s.repo.WorkspaceMembers().Update(ctx, member); err != nil {
+		return nil, fmt.Errorf("failed to update workspace member: %w", err)
+	}
+
+	return member, nil
+}
+
+// RemoveMember revokes a user's access to the workspace. The owner cannot be
+// removed.
+func (s *WorkspaceService) RemoveMember(ctx context.Context, actor Actor, workspaceID, userID uint) error {
+	if _, err := loadWorkspace(ctx, s.repo, actor, workspaceID, models.WorkspaceRoleManager); err != nil {
+		return err
+	}
+
+	member, err := s.repo.WorkspaceMembers().Get(ctx, workspaceID, userID)
+	if err != nil {
+		return lookupError("workspace member", err)
+	}
+	if member.Role == models.WorkspaceRoleOwner {
+		return fmt.Errorf("%w: the workspace owner cannot be removed", ErrConflict)
+	}
+
+	if err := s.repo.WorkspaceMembers().Delete(ctx, workspaceID, userID); err != nil {
+		return fmt.Errorf("failed to remove workspace member: %w", err)
+	}
+	return nil
+}
+
+// validateMemberRole accepts the roles that can be handed out to members.
+func validateMemberRole(role models.WorkspaceRole) error {
+	switch role {
+	case models.WorkspaceRoleManager, models.WorkspaceRoleDev, models.WorkspaceRoleViewer:
+		return nil
+	}
+	return fmt.Errorf("%w: role must be one of manager, dev, viewer", ErrInvalidInput)
+}
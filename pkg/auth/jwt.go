@@ -1,6 +1,10 @@
 package auth
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"os"
 	"time"
@@ -9,6 +13,14 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+// ErrTokenRevoked is returned by ValidateToken for tokens on the revocation list.
+var ErrTokenRevoked = errors.New("token has been revoked")
+
 type Claims struct {
 	UserID uint            `json:"user_id"`
 	Email  string          `json:"email"`
@@ -16,46 +28,118 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(user *models.User) (string, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "your-secret-key-change-this-in-production"
+// RevocationList reports whether the access token with the given ID (the jti
+// claim) has been revoked before its expiry, e.g. by logging out.
+type RevocationList interface {
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+}
+
+// AccessTokenTTL returns the lifetime of access tokens, configurable through
+// ACCESS_TOKEN_TTL as a Go duration (e.g. "15m").
+func AccessTokenTTL() time.Duration {
+	return durationFromEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)
+}
+
+// RefreshTokenTTL returns the lifetime of refresh tokens, configurable through
+// REFRESH_TOKEN_TTL as a Go duration (e.g. "720h").
+func RefreshTokenTTL() time.Duration {
+	return durationFromEnv("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)
+}
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// GenerateToken issues a short-lived access token for user with a unique jti
+// claim, signed with the active key of keys, and returns it together with its
+// claims.
+func GenerateToken(keys KeyManager, user *models.User) (string, *Claims, error) {
+	tokenID, err := randomHex(16)
+	if err != nil {
+		return "", nil, err
 	}
 
-	claims := Claims{
+	now := time.Now()
+	claims := &Claims{
 		UserID: user.ID,
 		Email:  user.Email,
 		Role:   user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL())),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
-}
-
-func ValidateToken(tokenString string) (*Claims, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "your-secret-key-change-this-in-production"
+	key := keys.SigningKey()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	signed, err := token.SignedString(key.signKey)
+	if err != nil {
+		return "", nil, err
 	}
+	return signed, claims, nil
+}
 
+// ValidateToken parses and verifies an access token against the key named by
+// its kid header, and rejects it if its jti is on the revocation list.
+func ValidateToken(ctx context.Context, keys KeyManager, tokenString string, revocations RevocationList) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := keys.VerificationKey(kid)
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+		// The algorithm is pinned by the key, never taken from the token.
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, errors.New("invalid signing method")
 		}
-		return []byte(secret), nil
+		return key.verifyKey, nil
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	if claims.ID == "" {
+		return nil, errors.New("token has no jti claim")
+	}
+	revoked, err := revocations.IsRevoked(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
 	}
 
-	return nil, errors.New("invalid token")
+	return claims, nil
+}
+
+// GenerateRefreshToken returns a random opaque refresh token. Only its hash
+// (see HashRefreshToken) should be stored.
+func GenerateRefreshToken() (string, error) {
+	return randomHex(32)
+}
+
+// HashRefreshToken returns the SHA-256 hex digest under which a refresh token
+// is stored.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	bytes := make([]byte, n)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
 }
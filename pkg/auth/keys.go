@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is a JWT signing or verification key identified by its kid.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+
+	signKey   interface{} // Nil for verification-only keys
+	verifyKey interface{}
+}
+
+// CanSign reports whether the key holds private (or symmetric) material.
+func (k *Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+// PublicKey returns the public half of an asymmetric key, or nil for HMAC keys.
+func (k *Key) PublicKey() crypto.PublicKey {
+	switch key := k.verifyKey.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+		return key
+	}
+	return nil
+}
+
+// NewHMACKey returns an HS256 key for a shared secret.
+func NewHMACKey(id string, secret []byte) *Key {
+	return &Key{ID: id, Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+}
+
+// NewRSAKey returns an RS256 signing key.
+func NewRSAKey(id string, private *rsa.PrivateKey) *Key {
+	return &Key{ID: id, Method: jwt.SigningMethodRS256, signKey: private, verifyKey: &private.PublicKey}
+}
+
+// NewEd25519Key returns an EdDSA signing key.
+func NewEd25519Key(id string, private ed25519.PrivateKey) *Key {
+	return &Key{ID: id, Method: jwt.SigningMethodEdDSA, signKey: private, verifyKey: private.Public()}
+}
+
+// ParseKeyPEM builds a key for alg ("RS256" or "EdDSA") from a PEM encoded
+// private key, or from a public key for a verification-only key that is being
+// rotated out.
+func ParseKeyPEM(id, alg string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	private := strings.Contains(block.Type, "PRIVATE KEY")
+
+	switch alg {
+	case jwt.SigningMethodRS256.Alg():
+		if private {
+			key, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			return NewRSAKey(id, key), nil
+		}
+		key, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, verifyKey: key}, nil
+
+	case jwt.SigningMethodEdDSA.Alg():
+		if private {
+			key, err := jwt.ParseEdPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			return NewEd25519Key(id, key.(ed25519.PrivateKey)), nil
+		}
+		key, err := jwt.ParseEdPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, verifyKey: key.(ed25519.PublicKey)}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported algorithm %q", alg)
+}
+
+// KeyManager provides the keys used to issue and verify tokens.
+type KeyManager interface {
+	// SigningKey returns the key new tokens are signed with.
+	SigningKey() *Key
+	// VerificationKey returns the key with the given kid, if it is known.
+	VerificationKey(kid string) (*Key, bool)
+	// Keys returns every key, the signing key first.
+	Keys() []*Key
+}
+
+type keyRing struct {
+	active *Key
+	keys   []*Key
+	byID   map[string]*Key
+}
+
+// NewKeyRing returns a KeyManager that signs with the key whose kid is
+// activeID and accepts tokens signed by any of keys. Keeping retired keys in
+// the ring lets tokens issued before a rotation stay valid until they expire.
+func NewKeyRing(activeID string, keys ...*Key) (KeyManager, error) {
+	ring := &keyRing{byID: make(map[string]*Key, len(keys))}
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, errors.New("key without kid")
+		}
+		if _, exists := ring.byID[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate kid %q", key.ID)
+		}
+		ring.byID[key.ID] = key
+	}
+
+	active, ok := ring.byID[activeID]
+	if !ok {
+		return nil, fmt.Errorf("active kid %q is not configured", activeID)
+	}
+	if !active.CanSign() {
+		return nil, fmt.Errorf("active kid %q has no private key", activeID)
+	}
+	ring.active = active
+
+	ring.keys = append(ring.keys, active)
+	for _, key := range keys {
+		if key != active {
+			ring.keys = append(ring.keys, key)
+		}
+	}
+	return ring, nil
+}
+
+func (r *keyRing) SigningKey() *Key {
+	return r.active
+}
+
+func (r *keyRing) VerificationKey(kid string) (*Key, bool) {
+	key, ok := r.byID[kid]
+	return key, ok
+}
+
+func (r *keyRing) Keys() []*Key {
+	return r.keys
+}
+
+// LoadKeyManager builds the KeyManager from the environment:
+//
+//   - JWT_KEYS lists keys as comma-separated "kid:alg:path" entries, where alg
+//     is RS256 or EdDSA and path points to a PEM file. Entries with a public
+//     key only are accepted for verification. JWT_ACTIVE_KID selects the
+//     signing key and defaults to the first entry.
+//   - Otherwise JWT_SECRET configures a single HS256 key with kid "default".
+//
+// When neither is set, APP_ENV=production refuses to start; any other
+// environment gets a random Ed25519 key that lives only as long as the
+// process.
+func LoadKeyManager() (KeyManager, error) {
+	if spec := os.Getenv("JWT_KEYS"); spec != "" {
+		var keys []*Key
+		for _, entry := range strings.Split(spec, ",") {
+			parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+			if len(parts) != 3 {
+				return nil, fmt.Errorf("invalid JWT_KEYS entry %q: expected kid:alg:path", entry)
+			}
+			data, err := os.ReadFile(parts[2])
+			if err != nil {
+				return nil, fmt.Errorf("failed to read key %q: %w", parts[0], err)
+			}
+			key, err := ParseKeyPEM(parts[0], parts[1], data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse key %q: %w", parts[0], err)
+			}
+			keys = append(keys, key)
+		}
+
+		activeID := os.Getenv("JWT_ACTIVE_KID")
+		if activeID == "" {
+			activeID = keys[0].ID
+		}
+		return NewKeyRing(activeID, keys...)
+	}
+
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		return NewKeyRing("default", NewHMACKey("default", []byte(secret)))
+	}
+
+	if os.Getenv("APP_ENV") == "production" {
+		return nil, errors.New("no JWT signing keys configured: set JWT_KEYS or JWT_SECRET")
+	}
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	log.Println("WARNING: no JWT signing keys configured, using an ephemeral key; tokens will not survive a restart")
+	return NewKeyRing("ephemeral", NewEd25519Key("ephemeral", private))
+}
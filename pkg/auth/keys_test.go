@@ -0,0 +1,377 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type noRevocations struct{}
+
+func (noRevocations) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	return false, nil
+}
+
+var (
+	testRSAOnce sync.Once
+	testRSA     *rsa.PrivateKey
+)
+
+// testRSAKey returns an RSA key shared by the tests, as generating one is slow.
+func testRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	testRSAOnce.Do(func() {
+		var err error
+		if testRSA, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			panic(err)
+		}
+	})
+	return testRSA
+}
+
+func testEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return private
+}
+
+// testKeys returns an HS256, an RS256 and an EdDSA key with kids "hs", "rs"
+// and "ed".
+func testKeys(t *testing.T) []*Key {
+	return []*Key{
+		NewHMACKey("hs", []byte("a shared secret of sufficient length")),
+		NewRSAKey("rs", testRSAKey(t)),
+		NewEd25519Key("ed", testEd25519Key(t)),
+	}
+}
+
+func publicPEM(t *testing.T, key interface{}) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func privatePEM(t *testing.T, key interface{}) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+// rsaPrivatePEM encodes key in the PKCS #1 form "openssl genrsa" writes.
+func rsaPrivatePEM(key *rsa.PrivateKey) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+// signClaims signs a valid set of claims for user 1 with method and key,
+// naming kid in the header.
+func signClaims(t *testing.T, method jwt.SigningMethod, kid string, key interface{}) string {
+	t.Helper()
+	now := time.Now()
+	token := jwt.NewWithClaims(method, &Claims{
+		UserID: 1,
+		Role:   models.RoleDev,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "jti",
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+		},
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestSignAndVerifyPerKid(t *testing.T) {
+	keys := testKeys(t)
+	user := &models.User{ID: 7, Email: "dev@example.com", Role: models.RoleDev}
+
+	tests := []struct {
+		kid string
+		alg string
+	}{
+		{"hs", "HS256"},
+		{"rs", "RS256"},
+		{"ed", "EdDSA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			ring, err := NewKeyRing(tt.kid, keys...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			signed, _, err := GenerateToken(ring, user)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			token, _, err := jwt.NewParser().ParseUnverified(signed, &Claims{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token.Header["kid"] != tt.kid || token.Header["alg"] != tt.alg {
+				t.Errorf("header kid=%v alg=%v, want kid=%s alg=%s", token.Header["kid"], token.Header["alg"], tt.kid, tt.alg)
+			}
+
+			claims, err := ValidateToken(context.Background(), ring, signed, noRevocations{})
+			if err != nil {
+				t.Fatalf("ValidateToken: %v", err)
+			}
+			if claims.UserID != user.ID {
+				t.Errorf("user ID %d, want %d", claims.UserID, user.ID)
+			}
+
+			// After a rotation the retired key still verifies the token.
+			for _, other := range tests {
+				if other.kid == tt.kid {
+					continue
+				}
+				rotated, err := NewKeyRing(other.kid, keys...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := ValidateToken(context.Background(), rotated, signed, noRevocations{}); err != nil {
+					t.Errorf("token rejected after rotating to %q: %v", other.kid, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateTokenRejects(t *testing.T) {
+	keys := testKeys(t)
+	ring, err := NewKeyRing("rs", keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPublic := publicPEM(t, &testRSAKey(t).PublicKey)
+	stranger := testEd25519Key(t)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		// The classic confusion attack: HMAC keyed with the public RSA key.
+		{"HS256 token naming an RS256 kid", signClaims(t, jwt.SigningMethodHS256, "rs", rsaPublic)},
+		{"EdDSA token naming an HS256 kid", signClaims(t, jwt.SigningMethodEdDSA, "hs", stranger)},
+		{"RS256 token naming an EdDSA kid", signClaims(t, jwt.SigningMethodRS256, "ed", testRSAKey(t))},
+		{"unsigned token", signClaims(t, jwt.SigningMethodNone, "rs", jwt.UnsafeAllowNoneSignatureType)},
+		{"unknown kid", signClaims(t, jwt.SigningMethodEdDSA, "retired", stranger)},
+		{"missing kid", signClaims(t, jwt.SigningMethodEdDSA, "", stranger)},
+		{"known kid, wrong key", signClaims(t, jwt.SigningMethodEdDSA, "ed", stranger)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ValidateToken(context.Background(), ring, tt.token, noRevocations{}); err == nil {
+				t.Fatal("token was accepted")
+			}
+		})
+	}
+}
+
+func TestNewKeyRing(t *testing.T) {
+	keys := testKeys(t)
+	public := &Key{ID: "old", Method: jwt.SigningMethodRS256, verifyKey: &testRSAKey(t).PublicKey}
+
+	tests := []struct {
+		name     string
+		activeID string
+		keys     []*Key
+		wantErr  string
+	}{
+		{"valid", "ed", append(keys, public), ""},
+		{"unknown active kid", "missing", keys, "not configured"},
+		{"verification-only active key", "old", append(keys, public), "no private key"},
+		{"duplicate kid", "hs", append(keys, NewHMACKey("hs", []byte("other"))), "duplicate kid"},
+		{"empty kid", "hs", append(keys, NewHMACKey("", []byte("other"))), "without kid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring, err := NewKeyRing(tt.activeID, tt.keys...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ring.SigningKey().ID != tt.activeID || ring.Keys()[0].ID != tt.activeID {
+				t.Errorf("signing key %q, first key %q, want %q", ring.SigningKey().ID, ring.Keys()[0].ID, tt.activeID)
+			}
+			if len(ring.Keys()) != len(tt.keys) {
+				t.Errorf("%d keys, want %d", len(ring.Keys()), len(tt.keys))
+			}
+		})
+	}
+}
+
+func TestParseKeyPEM(t *testing.T) {
+	rsaKey := testRSAKey(t)
+	edKey := testEd25519Key(t)
+	rsaPrivate := rsaPrivatePEM(rsaKey)
+	rsaPublic := publicPEM(t, &rsaKey.PublicKey)
+	edPrivate := privatePEM(t, edKey)
+	edPublic := publicPEM(t, edKey.Public())
+
+	tests := []struct {
+		name    string
+		alg     string
+		data    []byte
+		canSign bool
+		wantErr bool
+	}{
+		{"RSA private key", "RS256", rsaPrivate, true, false},
+		{"RSA public key", "RS256", rsaPublic, false, false},
+		{"Ed25519 private key", "EdDSA", edPrivate, true, false},
+		{"Ed25519 public key", "EdDSA", edPublic, false, false},
+		{"RSA key as EdDSA", "EdDSA", rsaPrivate, false, true},
+		{"Ed25519 key as RS256", "RS256", edPublic, false, true},
+		{"unsupported algorithm", "HS256", rsaPrivate, false, true},
+		{"not PEM", "RS256", []byte("secret"), false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseKeyPEM("kid", tt.alg, tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("got no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key.Method.Alg() != tt.alg || key.CanSign() != tt.canSign {
+				t.Errorf("alg %s, can sign %v, want %s, %v", key.Method.Alg(), key.CanSign(), tt.alg, tt.canSign)
+			}
+		})
+	}
+}
+
+func TestLoadKeyManager(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey := testRSAKey(t)
+	edKey := testEd25519Key(t)
+	rsaPath := filepath.Join(dir, "rs.pem")
+	edPath := filepath.Join(dir, "ed.pub.pem")
+	if err := os.WriteFile(rsaPath, rsaPrivatePEM(rsaKey), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(edPath, publicPEM(t, edKey.Public()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		env        map[string]string
+		wantActive string
+		wantKeys   int
+		wantErr    bool
+	}{
+		{"key files", map[string]string{"JWT_KEYS": "new:RS256:" + rsaPath + ", old:EdDSA:" + edPath}, "new", 2, false},
+		{"verification-only active key", map[string]string{"JWT_KEYS": "new:RS256:" + rsaPath + ",old:EdDSA:" + edPath, "JWT_ACTIVE_KID": "old"}, "", 0, true},
+		{"malformed entry", map[string]string{"JWT_KEYS": "new:" + rsaPath}, "", 0, true},
+		{"missing file", map[string]string{"JWT_KEYS": "new:RS256:" + filepath.Join(dir, "missing.pem")}, "", 0, true},
+		{"shared secret", map[string]string{"JWT_SECRET": "secret", "APP_ENV": "production"}, "default", 1, false},
+		{"production without keys", map[string]string{"APP_ENV": "production"}, "", 0, true},
+		{"development without keys", map[string]string{"APP_ENV": "development"}, "ephemeral", 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"JWT_KEYS", "JWT_ACTIVE_KID", "JWT_SECRET", "APP_ENV"} {
+				t.Setenv(name, tt.env[name])
+			}
+
+			keys, err := LoadKeyManager()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("got no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if keys.SigningKey().ID != tt.wantActive || len(keys.Keys()) != tt.wantKeys {
+				t.Errorf("active %q with %d keys, want %q with %d", keys.SigningKey().ID, len(keys.Keys()), tt.wantActive, tt.wantKeys)
+			}
+		})
+	}
+}
+
+func TestJWKSPublishesPublicKeysOnly(t *testing.T) {
+	rsaKey := testRSAKey(t)
+	keys := append(testKeys(t), &Key{ID: "old", Method: jwt.SigningMethodRS256, verifyKey: &rsaKey.PublicKey})
+	ring, err := NewKeyRing("rs", keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := JWKS(ring)
+	var kids []string
+	for _, jwk := range set.Keys {
+		kids = append(kids, jwk.KeyID)
+	}
+	if got := strings.Join(kids, ","); got != "rs,ed,old" {
+		t.Errorf("kids %s, want rs,ed,old", got)
+	}
+
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	// Private RSA and OKP members, and the symmetric "k".
+	for _, jwk := range raw.Keys {
+		for _, member := range []string{"d", "p", "q", "dp", "dq", "qi", "k"} {
+			if _, ok := jwk[member]; ok {
+				t.Errorf("key %v has private member %q", jwk["kid"], member)
+			}
+		}
+	}
+	if strings.Contains(string(data), "shared secret") {
+		t.Error("JWKS contains the HMAC secret")
+	}
+
+	rs := set.Keys[0]
+	if rs.KeyType != "RSA" || rs.Algorithm != "RS256" || rs.E != "AQAB" || rs.N == "" {
+		t.Errorf("RSA key %+v", rs)
+	}
+	ed := set.Keys[1]
+	if ed.KeyType != "OKP" || ed.Curve != "Ed25519" || ed.Algorithm != "EdDSA" || ed.X == "" {
+		t.Errorf("Ed25519 key %+v", ed)
+	}
+}
@@ -27,14 +27,163 @@ func Connect() (*gorm.DB, error) {
 }
 
 func Migrate(db *gorm.DB) error {
+	// Columns added once data already existed are backfilled right after
+	// AutoMigrate creates them.
+	backfillDone := db.Migrator().HasTable(&models.WorkflowStatus{}) &&
+		!db.Migrator().HasColumn(&models.WorkflowStatus{}, "done")
+	backfillCompleted := db.Migrator().HasTable(&models.Task{}) &&
+		!db.Migrator().HasColumn(&models.Task{}, "completed_at")
+	backfillCreatedBy := db.Migrator().HasTable(&models.Task{}) &&
+		!db.Migrator().HasColumn(&models.Task{}, "created_by_id")
+
 	// AutoMigrate will create tables, missing foreign keys, constraints, columns and indexes.
 	// It will change existing column’s type if its size, precision, nullable changed.
 	// It WON’T delete unused columns to protect your data.
-	return db.AutoMigrate(
+	if err := db.AutoMigrate(
 		&models.User{},
 		&models.Workspace{},
+		&models.WorkspaceMember{},
 		&models.Project{},
 		&models.Task{},
+		&models.WorkflowStatus{},
+		&models.WorkflowTransition{},
 		&models.TaskHistory{},
-	)
+		&models.TaskComment{},
+		&models.CommentMention{},
+		&models.TaskCommentEdit{},
+		&models.TaskDependency{},
+		&models.Label{},
+		&models.CustomField{},
+		&models.CustomFieldValue{},
+		&models.Attachment{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.NotificationPreference{},
+		&models.EmailDigestItem{},
+		&models.Notification{},
+		&models.AuditLog{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+	); err != nil {
+		return err
+	}
+
+	if err := addTaskConstraints(db); err != nil {
+		return err
+	}
+
+	// Label names are unique per workspace regardless of case, which a tag on
+	// the model cannot express.
+	if err := db.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_labels_workspace_name ON labels (workspace_id, LOWER(name))`).Error; err != nil {
+		return fmt.Errorf("failed to add label name index: %w", err)
+	}
+
+	if err := addSearchColumns(db); err != nil {
+		return err
+	}
+
+	if backfillDone {
+		if err := db.Exec(`UPDATE workflow_statuses SET done = TRUE WHERE name = ?`, models.TaskStatusDone).Error; err != nil {
+			return fmt.Errorf("failed to backfill done statuses: %w", err)
+		}
+	}
+	if backfillCompleted {
+		// Tasks already in a done status count as completed since their last update.
+		err := db.Exec(`
+			UPDATE tasks SET completed_at = updated_at
+			WHERE (EXISTS (SELECT 1 FROM workflow_statuses ws WHERE ws.project_id = tasks.project_id)
+					AND status IN (SELECT ws.name FROM workflow_statuses ws WHERE ws.project_id = tasks.project_id AND ws.done))
+				OR (NOT EXISTS (SELECT 1 FROM workflow_statuses ws WHERE ws.project_id = tasks.project_id)
+					AND status = ?)`,
+			models.TaskStatusDone,
+		).Error
+		if err != nil {
+			return fmt.Errorf("failed to backfill task completion: %w", err)
+		}
+	}
+	if backfillCreatedBy {
+		// Whoever recorded a task's CREATE history entry created it.
+		err := db.Exec(`
+			UPDATE tasks SET created_by_id = h.user_id
+			FROM task_histories h
+			WHERE h.task_id = tasks.id AND h.change_type = ?`,
+			models.HistoryChangeCreate,
+		).Error
+		if err != nil {
+			return fmt.Errorf("failed to backfill task creators: %w", err)
+		}
+	}
+
+	// Workspaces created before memberships existed only record their owner on
+	// the workspace row; give those owners a member row so they keep access.
+	return db.Exec(`
+		INSERT INTO workspace_members (workspace_id, user_id, role, created_at, updated_at)
+		SELECT id, owner_id, ?, NOW(), NOW() FROM workspaces
+		ON CONFLICT (workspace_id, user_id) DO NOTHING`,
+		models.WorkspaceRoleOwner,
+	).Error
+}
+
+// taskConstraints keeps invalid enum values out of the tasks table. Statuses
+// are checked for their shape only, since the valid set depends on each
+// project's workflow.
+var taskConstraints = map[string]string{
+	"chk_tasks_priority": "priority IN ('LOW', 'MEDIUM', 'HIGH')",
+	"chk_tasks_status":   "status ~ '^[A-Z][A-Z0-9_]*$'",
+}
+
+// addTaskConstraints adds the task CHECK constraints that are missing. They are
+// added NOT VALID so that rows written before validation existed don't block
+// startup; new writes are checked right away. Run cmd/repair-tasks to fix the
+// old rows and validate the constraints.
+func addTaskConstraints(db *gorm.DB) error {
+	for name, check := range taskConstraints {
+		if db.Migrator().HasConstraint(&models.Task{}, name) {
+			continue
+		}
+		sql := fmt.Sprintf("ALTER TABLE tasks ADD CONSTRAINT %s CHECK (%s) NOT VALID", name, check)
+		if err := db.Exec(sql).Error; err != nil {
+			return fmt.Errorf("failed to add constraint %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// searchColumns are the full-text search vectors kept by Postgres itself as
+// generated columns, so the models never read or write them. Task titles weigh
+// more than descriptions when ranking.
+var searchColumns = map[string]string{
+	"tasks": "setweight(to_tsvector('english', COALESCE(title, '')), 'A') || " +
+		"setweight(to_tsvector('english', COALESCE(description, '')), 'B')",
+	"task_comments": "to_tsvector('english', COALESCE(body, ''))",
+	"projects":      "to_tsvector('english', COALESCE(name, ''))",
+}
+
+// addSearchColumns adds the search_vector columns and their GIN indexes where
+// they are missing.
+func addSearchColumns(db *gorm.DB) error {
+	for table, expr := range searchColumns {
+		if !db.Migrator().HasColumn(table, "search_vector") {
+			sql := fmt.Sprintf("ALTER TABLE %s ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (%s) STORED", table, expr)
+			if err := db.Exec(sql).Error; err != nil {
+				return fmt.Errorf("failed to add search column to %s: %w", table, err)
+			}
+		}
+		sql := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_%s_search ON %s USING GIN (search_vector)", table, table)
+		if err := db.Exec(sql).Error; err != nil {
+			return fmt.Errorf("failed to add search index to %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// ValidateTaskConstraints checks every existing task against the task CHECK
+// constraints. It fails if any row still violates them.
+func ValidateTaskConstraints(db *gorm.DB) error {
+	for name := range taskConstraints {
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE tasks VALIDATE CONSTRAINT %s", name)).Error; err != nil {
+			return fmt.Errorf("failed to validate constraint %s: %w", name, err)
+		}
+	}
+	return nil
 }
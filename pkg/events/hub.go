@@ -0,0 +1,143 @@
+// Package events is an in-process publish/subscribe hub for pushing changes to
+// connected clients.
+package events
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is how many events may queue up for a subscriber before it
+// is considered too slow and dropped.
+const subscriberBuffer = 64
+
+// Event is a change within a project. IDs increase across all projects, so a
+// client can resume from the last ID it received.
+type Event struct {
+	ID        uint64
+	Type      string
+	ProjectID uint
+	Data      json.RawMessage
+}
+
+// Hub fans events out to the subscribers of each project and keeps the most
+// recent ones so that reconnecting clients can catch up. A nil Hub discards
+// everything published to it.
+type Hub struct {
+	mu          sync.Mutex
+	last        uint64
+	backlog     []Event
+	backlogSize int
+	subscribers map[uint]map[*Subscription]struct{}
+}
+
+// NewHub returns a hub that keeps the last backlogSize events for replay.
+func NewHub(backlogSize int) *Hub {
+	return &Hub{
+		// Start from the current time so IDs handed out before a restart
+		// are always older than this hub's backlog and cannot be mistaken
+		// for its own.
+		last:        uint64(time.Now().UnixMicro()),
+		backlogSize: backlogSize,
+		subscribers: make(map[uint]map[*Subscription]struct{}),
+	}
+}
+
+// Subscription receives the events of one project on C. C is closed when the
+// subscription is closed or the hub drops it for falling behind.
+type Subscription struct {
+	C <-chan Event
+	// Start is the ID of the last event published before subscribing.
+	Start uint64
+
+	c         chan Event
+	hub       *Hub
+	projectID uint
+}
+
+// Publish sends an event with data encoded as JSON to the subscribers of a
+// project. Subscribers whose buffer is full are dropped rather than waited
+// for; they can reconnect and resume.
+func (h *Hub) Publish(projectID uint, eventType string, data interface{}) {
+	if h == nil {
+		return
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("Events: failed to encode %s event: %v", eventType, err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.last++
+	event := Event{ID: h.last, Type: eventType, ProjectID: projectID, Data: payload}
+	h.backlog = append(h.backlog, event)
+	if len(h.backlog) > h.backlogSize {
+		h.backlog = h.backlog[len(h.backlog)-h.backlogSize:]
+	}
+
+	for sub := range h.subscribers[projectID] {
+		select {
+		case sub.c <- event:
+		default:
+			h.remove(sub)
+		}
+	}
+}
+
+// Subscribe starts receiving a project's events. When lastEventID is non-zero
+// the project's events published after it are returned for replay; resumed is
+// false if some of them are no longer kept, in which case the caller should
+// reload instead.
+func (h *Hub) Subscribe(projectID uint, lastEventID uint64) (sub *Subscription, missed []Event, resumed bool) {
+	c := make(chan Event, subscriberBuffer)
+	sub = &Subscription{C: c, c: c, hub: h, projectID: projectID}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sub.Start = h.last
+	if h.subscribers[projectID] == nil {
+		h.subscribers[projectID] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[projectID][sub] = struct{}{}
+
+	if lastEventID == 0 {
+		return sub, nil, true
+	}
+	oldest := h.last + 1
+	if len(h.backlog) > 0 {
+		oldest = h.backlog[0].ID
+	}
+	if lastEventID < oldest-1 || lastEventID > h.last {
+		return sub, nil, false
+	}
+	for _, event := range h.backlog {
+		if event.ID > lastEventID && event.ProjectID == projectID {
+			missed = append(missed, event)
+		}
+	}
+	return sub, missed, true
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// remove unregisters a subscription and closes its channel. h.mu must be held.
+func (h *Hub) remove(sub *Subscription) {
+	subs := h.subscribers[sub.projectID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subscribers, sub.projectID)
+	}
+	close(sub.c)
+}
@@ -0,0 +1,185 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+// ids returns the IDs of events.
+func ids(events []Event) []uint64 {
+	var ids []uint64
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
+
+func equalIDs(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSubscribeReplaysMissedEvents(t *testing.T) {
+	hub := NewHub(10)
+	first, _, _ := hub.Subscribe(1, 0)
+	start := first.Start
+	first.Close()
+
+	hub.Publish(1, "task.created", map[string]int{"id": 1}) // start+1
+	hub.Publish(2, "task.created", map[string]int{"id": 2}) // start+2, another project
+	hub.Publish(1, "task.updated", map[string]int{"id": 1}) // start+3
+
+	tests := []struct {
+		name        string
+		lastEventID uint64
+		want        []uint64
+	}{
+		{"fresh subscription", 0, nil},
+		{"missed everything", start, []uint64{start + 1, start + 3}},
+		{"missed the last one", start + 1, []uint64{start + 3}},
+		{"missed another project's event", start + 2, []uint64{start + 3}},
+		{"up to date", start + 3, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, missed, resumed := hub.Subscribe(1, tt.lastEventID)
+			defer sub.Close()
+			if !resumed {
+				t.Fatal("reported a reset")
+			}
+			if got := ids(missed); !equalIDs(got, tt.want) {
+				t.Errorf("missed %v, want %v", got, tt.want)
+			}
+			if sub.Start != start+3 {
+				t.Errorf("start %d, want %d", sub.Start, start+3)
+			}
+		})
+	}
+
+	sub, _, _ := hub.Subscribe(1, start+3)
+	defer sub.Close()
+	hub.Publish(2, "task.created", nil)
+	hub.Publish(1, "task.deleted", nil)
+	select {
+	case event := <-sub.C:
+		if event.ID != start+5 || event.Type != "task.deleted" || event.ProjectID != 1 {
+			t.Errorf("received %+v, want the task.deleted event of project 1", event)
+		}
+	default:
+		t.Fatal("published event was not delivered")
+	}
+}
+
+func TestSubscribeResetsAfterBacklogRotated(t *testing.T) {
+	hub := NewHub(2)
+	first, _, _ := hub.Subscribe(1, 0)
+	start := first.Start
+	first.Close()
+	for i := 0; i < 3; i++ {
+		hub.Publish(1, "task.updated", nil) // start+1 .. start+3, start+1 rotated out
+	}
+
+	sub, missed, resumed := hub.Subscribe(1, start)
+	sub.Close()
+	if resumed || missed != nil {
+		t.Errorf("resumed %v with %v after the backlog rotated past the last event ID", resumed, ids(missed))
+	}
+
+	sub, missed, resumed = hub.Subscribe(1, start+1)
+	sub.Close()
+	if want := []uint64{start + 2, start + 3}; !resumed || !equalIDs(ids(missed), want) {
+		t.Errorf("resumed %v with %v, want %v", resumed, ids(missed), want)
+	}
+}
+
+func TestSubscribeResetsAfterRestart(t *testing.T) {
+	before := NewHub(10)
+	before.Publish(1, "task.updated", nil)
+	sub, _, _ := before.Subscribe(1, 0)
+	lastBeforeRestart := sub.Start
+	sub.Close()
+
+	// IDs are based on the clock, so the new hub's are all larger.
+	time.Sleep(time.Millisecond)
+	hub := NewHub(10)
+
+	sub, missed, resumed := hub.Subscribe(1, lastBeforeRestart)
+	sub.Close()
+	if resumed || missed != nil {
+		t.Errorf("resumed %v with %v from an ID of the previous hub", resumed, ids(missed))
+	}
+
+	hub.Publish(1, "task.updated", nil)
+	sub, missed, resumed = hub.Subscribe(1, lastBeforeRestart)
+	sub.Close()
+	if resumed || missed != nil {
+		t.Errorf("resumed %v with %v from an ID older than the backlog", resumed, ids(missed))
+	}
+
+	// An ID the hub has not handed out yet, e.g. from a hub whose clock was
+	// ahead, is not trusted either.
+	sub, missed, resumed = hub.Subscribe(1, sub.Start+1)
+	sub.Close()
+	if resumed || missed != nil {
+		t.Errorf("resumed %v with %v from an ID in the future", resumed, ids(missed))
+	}
+}
+
+func TestPublishDropsSlowSubscribers(t *testing.T) {
+	hub := NewHub(10)
+	slow, _, _ := hub.Subscribe(1, 0)
+	other, _, _ := hub.Subscribe(2, 0)
+	defer other.Close()
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		hub.Publish(1, "task.updated", nil)
+	}
+
+	received := 0
+	for range slow.C {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Errorf("received %d events before the channel was closed, want %d", received, subscriberBuffer)
+	}
+	// Closing a dropped subscription is harmless.
+	slow.Close()
+
+	hub.Publish(2, "task.updated", nil)
+	select {
+	case _, ok := <-other.C:
+		if !ok {
+			t.Fatal("subscriber of another project was dropped")
+		}
+	default:
+		t.Fatal("subscriber of another project got nothing")
+	}
+}
+
+func TestSubscriptionClose(t *testing.T) {
+	hub := NewHub(10)
+	sub, _, _ := hub.Subscribe(1, 0)
+	sub.Close()
+	sub.Close()
+
+	if _, ok := <-sub.C; ok {
+		t.Fatal("channel still open after Close")
+	}
+	// Publishing to a project without subscribers still fills the backlog.
+	hub.Publish(1, "task.updated", nil)
+	if len(hub.subscribers) != 0 || len(hub.backlog) != 1 {
+		t.Errorf("%d projects with subscribers, %d events kept, want 0 and 1", len(hub.subscribers), len(hub.backlog))
+	}
+}
+
+func TestNilHubDiscardsEvents(t *testing.T) {
+	var hub *Hub
+	hub.Publish(1, "task.updated", nil)
+}
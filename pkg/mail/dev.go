@@ -0,0 +1,53 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// Log writes messages to the application log instead of sending them, for
+// development.
+type Log struct{}
+
+func NewLog() *Log {
+	return &Log{}
+}
+
+func (l *Log) Send(ctx context.Context, msg Message) error {
+	log.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Text)
+	return nil
+}
+
+// File writes each message as an .eml file below a directory instead of
+// sending it, for development.
+type File struct {
+	dir  string
+	from string
+}
+
+// NewFile returns a mailer writing to dir, creating the directory if needed.
+func NewFile(dir, from string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	return &File{dir: dir, from: from}, nil
+}
+
+func (f *File) Send(ctx context.Context, msg Message) error {
+	body, err := compose(f.from, msg)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(f.dir, time.Now().UTC().Format("20060102T150405")+"-*.eml")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		return err
+	}
+	return tmp.Close()
+}
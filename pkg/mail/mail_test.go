@@ -0,0 +1,140 @@
+package mail
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestCompose(t *testing.T) {
+	msg := Message{
+		To:      `"Zoë Brontë" <zoe@example.com>`,
+		Subject: "Überprüfung: \"Launch\"\r\nBcc: attacker@example.com",
+		Text:    "Hi Zoë,\nthe launch moved to Friday.",
+		HTML:    "<p>Hi Zoë,</p><p>the launch moved to <b>Friday</b>.</p>",
+	}
+
+	data, err := compose("Task Manager <noreply@tasks.example.com>", msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("\nBcc:")) {
+		t.Fatal("line break in the subject started a new header")
+	}
+	for _, line := range strings.Split(string(data), "\r\n") {
+		if strings.ContainsRune(line, '\n') {
+			t.Fatalf("bare line feed in %q", line)
+		}
+	}
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := parsed.Header
+	if len(header["Bcc"]) != 0 {
+		t.Fatalf("got a Bcc header %q", header["Bcc"])
+	}
+
+	subject := header.Get("Subject")
+	if !strings.HasPrefix(subject, "=?utf-8?q?") {
+		t.Errorf("subject %q is not Q-encoded", subject)
+	}
+	decoded, err := new(mime.WordDecoder).DecodeHeader(subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded != msg.Subject {
+		t.Errorf("subject decodes to %q, want %q", decoded, msg.Subject)
+	}
+
+	to, err := header.AddressList("To")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(to) != 1 || to[0].Name != "Zoë Brontë" || to[0].Address != "zoe@example.com" {
+		t.Errorf("To %v", to)
+	}
+	from, err := header.AddressList("From")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(from) != 1 || from[0].Name != "Task Manager" || from[0].Address != "noreply@tasks.example.com" {
+		t.Errorf("From %v", from)
+	}
+	if id := header.Get("Message-ID"); !strings.HasSuffix(id, "@tasks.example.com>") {
+		t.Errorf("Message-ID %q is not in the sender's domain", id)
+	}
+	if _, err := header.Date(); err != nil {
+		t.Errorf("Date: %v", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type %q (%v)", header.Get("Content-Type"), err)
+	}
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	for _, want := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		part, err := reader.NextRawPart()
+		if err != nil {
+			t.Fatalf("%s part: %v", want.contentType, err)
+		}
+		if got := part.Header.Get("Content-Type"); got != want.contentType {
+			t.Errorf("part Content-Type %q, want %q", got, want.contentType)
+		}
+		if got := part.Header.Get("Content-Transfer-Encoding"); got != "quoted-printable" {
+			t.Errorf("part Content-Transfer-Encoding %q", got)
+		}
+		body, err := io.ReadAll(quotedprintable.NewReader(part))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Line breaks go out as CRLF, as MIME requires.
+		if string(body) != strings.ReplaceAll(want.body, "\n", "\r\n") {
+			t.Errorf("%s body %q, want %q", want.contentType, body, want.body)
+		}
+	}
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("unexpected third part (%v)", err)
+	}
+}
+
+func TestComposeTextOnly(t *testing.T) {
+	data, err := compose("noreply@example.com", Message{To: "zoe@example.com", Subject: "Plain", Text: "Hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(data), "Content-Transfer-Encoding"); got != 1 {
+		t.Errorf("%d parts, want only the text part", got)
+	}
+	if !strings.Contains(string(data), "\r\nSubject: Plain\r\n") {
+		t.Errorf("plain ASCII subject was encoded:\n%s", data)
+	}
+}
+
+func TestComposeRejectsInvalidAddresses(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+	}{
+		{"recipient", "noreply@example.com", "not an address"},
+		{"header injection in recipient", "noreply@example.com", "zoe@example.com\r\nBcc: attacker@example.com"},
+		{"sender", "nobody", "zoe@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := compose(tt.from, Message{To: tt.to, Subject: "Hi", Text: "Hello"}); err == nil {
+				t.Fatal("got no error")
+			}
+		})
+	}
+}
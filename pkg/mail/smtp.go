@@ -0,0 +1,69 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/mail"
+	"net/smtp"
+)
+
+// SMTPConfig describes an SMTP relay. Username and Password may be empty for
+// relays that do not require authentication.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// SMTP sends messages through an SMTP relay, upgrading the connection with
+// STARTTLS when the server supports it.
+type SMTP struct {
+	config SMTPConfig
+	sender string
+}
+
+func NewSMTP(config SMTPConfig) (*SMTP, error) {
+	if config.Host == "" || config.Port == "" {
+		return nil, errors.New("SMTP mail needs SMTP_HOST and SMTP_PORT")
+	}
+	sender, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, err
+	}
+	return &SMTP{config: config, sender: sender.Address}, nil
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	body, err := compose(s.config.From, msg)
+	if err != nil {
+		return err
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		// PlainAuth refuses to send credentials over an unencrypted
+		// connection to anything but localhost.
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	}
+	addr := net.JoinHostPort(s.config.Host, s.config.Port)
+
+	// net/smtp has no context support, so a cancelled context only stops
+	// the caller from waiting.
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, s.sender, []string{to.Address}, body)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
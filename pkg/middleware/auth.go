@@ -5,12 +5,14 @@ import (
 	"strings"
 
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/models"
+	"github.com/Swarnadip-Dey/Collaborative-taskmanager/internal/repository"
 	"github.com/Swarnadip-Dey/Collaborative-taskmanager/pkg/auth"
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware validates JWT token
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware validates JWT token and loads the user it was issued to.
+// Revoked tokens and tokens of deleted or disabled users are rejected.
+func AuthMiddleware(users repository.UserRepository, keys auth.KeyManager, revocations auth.RevocationList) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -28,17 +30,62 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		token := parts[1]
-		claims, err := auth.ValidateToken(token)
+		claims, err := auth.ValidateToken(c.Request.Context(), keys, token, revocations)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
 			c.Abort()
 			return
 		}
 
-		// Set user info in context
-		c.Set("user_id", claims.UserID)
-		c.Set("user_email", claims.Email)
-		c.Set("user_role", claims.Role)
+		user, err := users.GetByID(c.Request.Context(), claims.UserID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
+			c.Abort()
+			return
+		}
+		if user.IsDisabled() {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "account disabled"})
+			c.Abort()
+			return
+		}
+
+		// Set user info in context. The role comes from the database so that
+		// role changes take effect without waiting for the token to expire.
+		c.Set("user_id", user.ID)
+		c.Set("user_email", user.Email)
+		c.Set("user_role", user.Role)
+		c.Set("password_reset_required", user.PasswordResetRequired)
+		c.Set("token_id", claims.ID)
+		c.Set("token_expires_at", claims.ExpiresAt.Time)
+
+		c.Next()
+	}
+}
+
+// QueryTokenMiddleware accepts the access token as the access_token query
+// parameter when no Authorization header is sent, for clients that cannot set
+// headers, such as the browser EventSource API. It must run before
+// AuthMiddleware. URLs show up in request logs, so only use it on routes that
+// need it.
+func QueryTokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.Query("access_token"); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		c.Next()
+	}
+}
+
+// PasswordResetMiddleware blocks users whose password was reset by an admin
+// until they have chosen a new one. It must run after AuthMiddleware.
+func PasswordResetMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetBool("password_reset_required") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "password reset required"})
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
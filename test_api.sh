@@ -11,6 +11,10 @@ echo -e "${BLUE}=== Testing Collaborative Task Manager API ===${NC}\n"
 # Base URL
 BASE_URL="http://localhost:8080"
 
+# Admin credentials (start the server with matching BOOTSTRAP_ADMIN_* variables)
+ADMIN_EMAIL="${ADMIN_EMAIL:-admin@test.com}"
+ADMIN_PASSWORD="${ADMIN_PASSWORD:-password123}"
+
 # Test 1: Health Check
 echo -e "${BLUE}1. Testing Health Check (GET /api/ping)${NC}"
 RESPONSE=$(curl -s -w "\n%{http_code}" $BASE_URL/api/ping)
@@ -27,24 +31,44 @@ echo ""
 echo -e "${BLUE}2. Testing Register Manager (POST /api/register)${NC}"
 RESPONSE=$(curl -s -w "\n%{http_code}" -X POST $BASE_URL/api/register \
   -H "Content-Type: application/json" \
-  -d '{"username":"testmanager","email":"testmanager@test.com","password":"password123","role":"manager"}')
+  -d '{"username":"testmanager","email":"testmanager@test.com","password":"password123"}')
 HTTP_CODE=$(echo "$RESPONSE" | tail -n1)
 BODY=$(echo "$RESPONSE" | sed '$d')
 if [ "$HTTP_CODE" = "201" ]; then
-    MANAGER_TOKEN=$(echo "$BODY" | grep -o '"token":"[^"]*' | cut -d'"' -f4)
-    echo -e "${GREEN}✓ PASS${NC} - Manager registered"
-    echo "Token: ${MANAGER_TOKEN:0:50}..."
+    MANAGER_ID=$(echo "$BODY" | grep -o '"id":[0-9]*' | head -1 | cut -d':' -f2)
+    echo -e "${GREEN}✓ PASS${NC} - Manager registered (ID: $MANAGER_ID)"
 else
     echo -e "${RED}✗ FAIL${NC} - HTTP $HTTP_CODE"
     echo "$BODY"
 fi
 echo ""
 
+# Promote the manager (Admin)
+echo -e "${BLUE}2b. Testing Promote to Manager (PUT /api/admin/users/:id/role)${NC}"
+ADMIN_TOKEN=$(curl -s -X POST $BASE_URL/api/login \
+  -H "Content-Type: application/json" \
+  -d "{\"email\":\"$ADMIN_EMAIL\",\"password\":\"$ADMIN_PASSWORD\"}" | grep -o '"token":"[^"]*' | cut -d'"' -f4)
+RESPONSE=$(curl -s -w "\n%{http_code}" -X PUT $BASE_URL/api/admin/users/$MANAGER_ID/role \
+  -H "Authorization: Bearer $ADMIN_TOKEN" \
+  -H "Content-Type: application/json" \
+  -d '{"role":"manager"}')
+HTTP_CODE=$(echo "$RESPONSE" | tail -n1)
+if [ "$HTTP_CODE" = "200" ]; then
+    MANAGER_TOKEN=$(curl -s -X POST $BASE_URL/api/login \
+      -H "Content-Type: application/json" \
+      -d '{"email":"testmanager@test.com","password":"password123"}' | grep -o '"token":"[^"]*' | cut -d'"' -f4)
+    echo -e "${GREEN}✓ PASS${NC} - Manager promoted"
+    echo "Token: ${MANAGER_TOKEN:0:50}..."
+else
+    echo -e "${RED}✗ FAIL${NC} - HTTP $HTTP_CODE"
+fi
+echo ""
+
 # Test 3: Register Developer
 echo -e "${BLUE}3. Testing Register Developer (POST /api/register)${NC}"
 RESPONSE=$(curl -s -w "\n%{http_code}" -X POST $BASE_URL/api/register \
   -H "Content-Type: application/json" \
-  -d '{"username":"testdev","email":"testdev@test.com","password":"password123","role":"dev"}')
+  -d '{"username":"testdev","email":"testdev@test.com","password":"password123"}')
 HTTP_CODE=$(echo "$RESPONSE" | tail -n1)
 BODY=$(echo "$RESPONSE" | sed '$d')
 if [ "$HTTP_CODE" = "201" ]; then
@@ -99,6 +123,20 @@ else
 fi
 echo ""
 
+# Invite the developer to the workspace (Manager)
+echo -e "${BLUE}6b. Testing Invite Member (POST /api/manager/workspaces/:id/members)${NC}"
+RESPONSE=$(curl -s -w "\n%{http_code}" -X POST $BASE_URL/api/manager/workspaces/$WORKSPACE_ID/members \
+  -H "Authorization: Bearer $MANAGER_TOKEN" \
+  -H "Content-Type: application/json" \
+  -d '{"email":"testdev@test.com","role":"dev"}')
+HTTP_CODE=$(echo "$RESPONSE" | tail -n1)
+if [ "$HTTP_CODE" = "201" ]; then
+    echo -e "${GREEN}✓ PASS${NC} - Developer invited"
+else
+    echo -e "${RED}✗ FAIL${NC} - HTTP $HTTP_CODE"
+fi
+echo ""
+
 # Test 7: Create Project (Manager)
 echo -e "${BLUE}7. Testing Create Project (POST /api/manager/projects)${NC}"
 RESPONSE=$(curl -s -w "\n%{http_code}" -X POST $BASE_URL/api/manager/projects \